@@ -19,20 +19,42 @@ namespace App {
         matches?: string[];
     }
 
+    interface gogrepPackageResult {
+        err?: string;
+        results?: gogrepResult[];
+    }
+
     interface corpusInfo {
         Version: number;
+        WithComments: boolean;
         Repositories: repositoryInfo[];
+        Dependencies?: dependencyInfo[];
+    }
+
+    interface dependencyInfo {
+        From: string;
+        To: string;
+        Module: string;
+        Indirect: boolean;
     }
 
     interface repositoryInfo {
         Name: string;
         Tags: string[];
+        Source: string;
         Git: string;
+        SourceModule: string;
         Commit: string;
+        ContentHash: string;
+        ConfigHash: string;
+        GoVersion: string;
         Size: number;
         MinifiedSize: number;
         SLOC: number;
         Files: repositoryFileInfo[];
+        SkippedFiles?: {Name: string, Error: string}[];
+        Modules?: moduleInfo[];
+        Packages?: packageInfo[];
     }
 
     interface repositoryFileInfo {
@@ -40,10 +62,62 @@ namespace App {
         Flags: number;
         SLOC: number;
         MaxDepth: number;
+        NodeKinds?: string;
+        Hash?: string;
+        Directives?: {[name: string]: number};
+        BuildConstraint?: string;
+        FilenameTags?: string[];
+        GoVersion?: string;
+        Module?: number;
+        PkgPath?: string;
+    }
+
+    interface packageInfo {
+        Dir: string;
+        Path: string;
+        Name: string;
+        IsExternalTest: boolean;
+        SLOC: number;
+        Files: number[];
+    }
+
+    interface moduleInfo {
+        Path: string;
+        Dir: string;
+        Version: string;
+        GoVersion: string;
+        Toolchain?: string;
+        Requires?: {Path: string, Version: string, Indirect: boolean}[];
+        Replaces?: {Old: string, New: string}[];
+    }
+
+    // See makecorpus/ident_index.go for the format description.
+    interface identIndex {
+        NumFiles: number;
+        Common: string[];
+        Postings: {[name: string]: number[]};
+    }
+
+    // See makecorpus/decl_index.go for the format description.
+    interface declInfo {
+        Kind: string;
+        Name: string;
+        Receiver?: string;
+        TypeKind?: string;
+        Exported: boolean;
+        Signature: string;
+        Line: number;
+        Column: number;
+    }
+
+    interface declIndex {
+        Files: declInfo[][];
     }
 
     class RepoData {
         files: RepoFileData[] = [];
+        identIndex: identIndex = null;
+        declIndex: declIndex = null;
     }
 
     class RepoFileData {
@@ -56,16 +130,51 @@ namespace App {
         filter: string;
         fileFlags: number;
         fileMaxDepth: number;
+        fileGoVersion: string;
+        fileModule: string;
+        filePkgPath: string;
+        filePkgName: string;
+        fileNodeKinds: string;
+        fileCanMatch: boolean;
+        fileDirectives: {[name: string]: number};
+        fileBuildConstraint: string;
+        fileFilenameTags: string[];
         targetName: string;
         targetSrc: string;
+        repoRequires: string[];
+    }
+
+    interface gogrepPackageArgs {
+        pattern: string;
+        filter: string;
+        files: gogrepArgs[];
     }
 
     declare function gogrep(args: gogrepArgs): gogrepResult;
+    declare function gogrepPackage(args: gogrepPackageArgs): gogrepPackageResult;
+
+    interface gogrepDeclsArgs extends gogrepArgs {
+        decls: declInfo[];
+    }
+
+    declare function gogrepDecls(args: gogrepDeclsArgs): gogrepResult;
+    declare function gogrepPatternIdents(pattern: string): string[];
 
     const appState = {
         metadata: <corpusInfo>(null),
         corpus: new Map<string, RepoData>(),
 
+        // Whether the comments-preserving archives should be loaded.
+        withComments: false,
+
+        // Whether all files of a package should be matched together,
+        // so the pkg filters can be used.
+        packageMode: false,
+
+        // Whether the filter should be applied to the declarations index
+        // instead of the pattern matches. It takes precedence over the package mode.
+        declMode: false,
+
         go: null,
         wasm: null,
 
@@ -80,6 +189,14 @@ namespace App {
         filesScanned: 0,
         slocProcessed: 0,
         hits: 0,
+
+        // fileFlags maps a repository name to its files flags
+        // with the IsDuplicate bit computed for the scanned repositories.
+        fileFlags: new Map<string, number[]>(),
+
+        // Package keys are "repo:index" strings.
+        packagesScanned: new Set<string>(),
+        packagesMatched: new Set<string>(),
     };
 
     function updateStatus(status: string) {
@@ -171,6 +288,12 @@ namespace App {
         } else {
             parts.push(`<p><i>Frequency score: 0</i></p>`);
         }
+        if (appState.packagesScanned.size !== 0) {
+            let matched = appState.packagesMatched.size;
+            let scanned = appState.packagesScanned.size;
+            let percentage = 100.0 * (matched / scanned);
+            parts.push(`<p><i>Packages with matches: ${matched.toLocaleString()} of ${scanned.toLocaleString()} (${percentage.toFixed(2)}%)</i></p>`);
+        }
         parts.push(`<p><i>Time elapsed: ${elapsedSeconds.toFixed(2)} sec</i></p>`);
         for (let e of sortedMatches) {
             let [m, num] = e;
@@ -180,7 +303,134 @@ namespace App {
         $results.innerHTML += '<ol>' + parts.join('') + '</ol>';
     }
 
-    function runQueryRecursive(pattern: string, filter: string, toScan: repositoryInfo[]) {
+    let filePackagesCache = new Map<string, number[]>();
+
+    // filePackages maps the repository file indexes to their package indexes.
+    function filePackages(repo: repositoryInfo): number[] {
+        let result = filePackagesCache.get(repo.Name);
+        if (result) {
+            return result;
+        }
+        result = [];
+        for (let i = 0; i < (repo.Packages || []).length; i++) {
+            for (let fileIndex of repo.Packages[i].Files) {
+                result[fileIndex] = i;
+            }
+        }
+        filePackagesCache.set(repo.Name, result);
+        return result;
+    }
+
+    // isDuplicateFlag is filebits.IsDuplicate.
+    const isDuplicateFlag = 1 << 17;
+
+    // scannedFileFlags returns the files flags of the given repositories
+    // with the IsDuplicate bit recomputed against these repositories only.
+    // The corpus-wide bit can't be used as is: the original copy of a file
+    // may belong to a repository that is not scanned, so none of the copies
+    // would be matched. The repos should be in the corpus order,
+    // so the original copy is the same as in the corpus when it's scanned.
+    function scannedFileFlags(repos: repositoryInfo[]): Map<string, number[]> {
+        let seen = new Set<string>();
+        let result = new Map<string, number[]>();
+        for (let repo of repos) {
+            result.set(repo.Name, repo.Files.map(f => {
+                let flags = f.Flags & ~isDuplicateFlag;
+                if (f.Hash) {
+                    if (seen.has(f.Hash)) {
+                        flags |= isDuplicateFlag;
+                    } else {
+                        seen.add(f.Hash);
+                    }
+                }
+                return flags;
+            }));
+        }
+        return result;
+    }
+
+    let repoRequiresCache = new Map<string, string[]>();
+
+    // repoRequires returns the module paths required by any of the repository modules.
+    function repoRequires(repo: repositoryInfo): string[] {
+        let result = repoRequiresCache.get(repo.Name);
+        if (result) {
+            return result;
+        }
+        let paths = new Set<string>();
+        for (let mod of repo.Modules || []) {
+            for (let r of mod.Requires || []) {
+                paths.add(r.Path);
+            }
+        }
+        result = [...paths];
+        repoRequiresCache.set(repo.Name, result);
+        return result;
+    }
+
+    // findCandidateFiles returns a set of file indexes that contain all
+    // of the given identifiers, or null if the index can't help.
+    function findCandidateFiles(index: identIndex, idents: string[]): Set<number> {
+        if (!index) {
+            return null;
+        }
+        let common = new Set(index.Common);
+        let result: Set<number> = null;
+        for (let ident of idents) {
+            if (common.has(ident)) {
+                continue;
+            }
+            let files = new Set<number>();
+            if (Object.prototype.hasOwnProperty.call(index.Postings, ident)) {
+                let fileIndex = 0;
+                for (let delta of index.Postings[ident]) {
+                    fileIndex += delta;
+                    files.add(fileIndex);
+                }
+            }
+            if (result === null) {
+                result = files;
+            } else {
+                result = new Set([...result].filter(i => files.has(i)));
+            }
+        }
+        return result;
+    }
+
+    function shortenFilename(filename: string): string {
+        const maxLength = 50;
+        if (filename.length <= maxLength) {
+            return filename;
+        }
+        let nameParts = filename.split('/');
+        let baseName = nameParts[nameParts.length-1];
+        if (baseName.length >= maxLength) {
+            return '{...}/' + baseName;
+        }
+        let prefix = nameParts.slice(0, -1).join('/');
+        let prefixLen = maxLength - baseName.length - 4;
+        return prefix.substr(0, prefixLen) + '{...}/' + baseName;
+    }
+
+    // queryUnits splits the repository files into the groups
+    // that are passed to the matcher together.
+    // In the package mode, every package is a single unit;
+    // the files that don't belong to any package are matched alone.
+    function queryUnits(repo: repositoryInfo): number[][] {
+        if (appState.declMode || !appState.packageMode || !repo.Packages) {
+            return repo.Files.map((_, i) => [i]);
+        }
+        let units = repo.Packages.map(pkg => pkg.Files);
+        let packages = filePackages(repo);
+        for (let i = 0; i < repo.Files.length; i++) {
+            if (packages[i] === undefined) {
+                units.push([i]);
+            }
+        }
+        return units;
+    }
+
+    function runQueryRecursive(pattern: string, filter: string, patternIdents: string[], toScan: repositoryInfo[]) {
         if (toScan.length == 0) {
             searchDone();
             return;
@@ -188,68 +438,116 @@ namespace App {
 
         let repo = toScan.pop();
         let repoData = appState.corpus.get(repo.Name);
-        let files = repoData.files; 
-        doChunks(files.length,
-            i => {
+        let files = repoData.files;
+        let candidates = findCandidateFiles(repoData.identIndex, patternIdents);
+        let packages = filePackages(repo);
+        let units = queryUnits(repo);
+        let requires = repoRequires(repo);
+        let fileFlags = appState.fileFlags.get(repo.Name);
+
+        let fileArgs = (i: number): gogrepArgs => {
+            let fileInfo = repo.Files[i];
+            let pkgIndex = packages[i];
+            return {
+                pattern: pattern,
+                filter: filter,
+                fileFlags: fileFlags[i],
+                fileMaxDepth: fileInfo.MaxDepth,
+                fileGoVersion: fileInfo.GoVersion || '',
+                fileModule: fileInfo.PkgPath ? repo.Modules[fileInfo.Module].Path : '',
+                filePkgPath: fileInfo.PkgPath || '',
+                filePkgName: pkgIndex === undefined ? '' : repo.Packages[pkgIndex].Name,
+                fileNodeKinds: fileInfo.NodeKinds || '',
+                fileCanMatch: candidates === null || candidates.has(i),
+                fileDirectives: fileInfo.Directives || {},
+                fileBuildConstraint: fileInfo.BuildConstraint || '',
+                fileFilenameTags: fileInfo.FilenameTags || [],
+                targetName: files[i].name,
+                targetSrc: files[i].contents,
+                repoRequires: requires,
+            };
+        };
+
+        // handleResult returns false if the query should be stopped.
+        let handleResult = (i: number, result: gogrepResult): boolean => {
+            let fileInfo = repo.Files[i];
+            let pkgIndex = packages[i];
+            let pkgKey = pkgIndex === undefined ? '' : `${repo.Name}:${pkgIndex}`;
+            if (result.err) {
+                console.error(`grepping ${files[i].name}: ${result.err}`);
+                let canContinue = result.err.includes('parse Go:');
+                if (canContinue) {
+                    return true;
+                }
+                appState.runError = result.err;
+                appState.running = false;
+                return false;
+            }
+            if (!result.skipped) {
+                appState.slocProcessed += fileInfo.SLOC;
+                if (pkgKey) {
+                    appState.packagesScanned.add(pkgKey);
+                }
+            }
+            appState.filesScanned++;
+            if (!result.matches) {
+                return true;
+            }
+            appState.hits += result.matches.length;
+            if (pkgKey && result.matches.length !== 0) {
+                appState.packagesMatched.add(pkgKey);
+            }
+            for (let m of result.matches) {
+                if (appState.searchResults.size >= 1000) {
+                    break;
+                }
+                if (appState.searchResults.has(m)) {
+                    appState.searchResults.set(m, appState.searchResults.get(m) + 1);
+                } else {
+                    appState.searchResults.set(m, 1);
+                }
+            }
+            return true;
+        };
+
+        doChunks(units.length,
+            unitIndex => {
                 if (!appState.running) {
                     return false;
                 }
-                let f = files[i];
-                let filename = f.name;
-                const maxLength = 50;
-                if (filename.length > maxLength) {
-                    let nameParts = filename.split('/');
-                    let baseName = nameParts[nameParts.length-1];
-                    let shorterName = '';
-                    if (baseName.length >= maxLength) {
-                        shorterName = '{...}/' + baseName;
-                    } else {
-                        let prefix = nameParts.slice(0, -1).join('/');
-                        let prefixLen = maxLength - baseName.length - 4;
-                        shorterName = prefix.substr(0, prefixLen) + '{...}/' + baseName;
-                    }
-                    filename = shorterName;
+                let unit = units[unitIndex];
+                if (unit.length === 0) {
+                    return true;
                 }
-                updateStatus(`processing ${filename}`);
+                updateStatus(`processing ${shortenFilename(files[unit[0]].name)}`);
                 let $progress = document.getElementById('search-progress');
                 let progressValue = Math.round((appState.filesScanned / appState.filesTotal) * 100);
                 $progress.innerHTML = `Progress: ${progressValue}% (hits: ${appState.hits})`;
 
-                let fileInfo = repo.Files[i];
-                let result = gogrep({
+                if (appState.declMode) {
+                    let args = <gogrepDeclsArgs>(fileArgs(unit[0]));
+                    // The sources are not needed for the decls search.
+                    args.targetSrc = '';
+                    args.decls = repoData.declIndex ? repoData.declIndex.Files[unit[0]] : [];
+                    return handleResult(unit[0], gogrepDecls(args));
+                }
+                if (!appState.packageMode) {
+                    return handleResult(unit[0], gogrep(fileArgs(unit[0])));
+                }
+                let pkgResult = gogrepPackage({
                     pattern: pattern,
                     filter: filter,
-                    fileFlags: fileInfo.Flags,
-                    fileMaxDepth: fileInfo.MaxDepth,
-                    targetName: f.name,
-                    targetSrc: f.contents,
+                    files: unit.map(fileArgs),
                 });
-                if (result.err) {
-                    console.error(`grepping ${f.name}: ${result.err}`);
-                    let canContinue = result.err.includes('parse Go:');
-                    if (canContinue) {
-                        return true;
-                    }
-                    appState.runError = result.err;
+                if (pkgResult.err) {
+                    console.error(`grepping ${repo.Name} package: ${pkgResult.err}`);
+                    appState.runError = pkgResult.err;
                     appState.running = false;
                     return false;
                 }
-                if (!result.skipped) {
-                    appState.slocProcessed += fileInfo.SLOC;
-                }
-                appState.filesScanned++;
-                if (!result.matches) {
-                    return true;
-                }
-                appState.hits += result.matches.length;
-                for (let m of result.matches) {
-                    if (appState.searchResults.size >= 1000) {
-                        break;
-                    }
-                    if (appState.searchResults.has(m)) {
-                        appState.searchResults.set(m, appState.searchResults.get(m) + 1);
-                    } else {
-                        appState.searchResults.set(m, 1);
+                for (let j = 0; j < unit.length; j++) {
+                    if (!handleResult(unit[j], pkgResult.results[j])) {
+                        return false;
                     }
                 }
                 return true;
@@ -258,20 +556,40 @@ namespace App {
                 if (stopped) {
                     searchDone();
                 } else {
-                    runQueryRecursive(pattern, filter, toScan);
+                    runQueryRecursive(pattern, filter, patternIdents, toScan);
                 }
             });
     }
 
     function loadRepo(repo: repositoryInfo) {
         updateStatus(`loading ${repo.Name} repository...`);
-        return fetch(`corpus-output/${repo.Name}.tar.gz`).
+        let suffix = appState.withComments ? '.comments.tar.gz' : '.tar.gz';
+        return fetch(`corpus-output/${repo.Name}${suffix}`).
             then(result => new Promise<loadRepoResult>((resolve, reject) => {
                 result.arrayBuffer().
                     then(b => resolve({repo: repo, archive: new Uint8Array(b)}))
             }));
     }
 
+    // loadIndex loads the <repo>.<kind>.json index.
+    // The indexes are optional: the identifiers index only makes
+    // the search faster and the declarations index is only
+    // needed for the decl search mode.
+    function loadIndex(repo: repositoryInfo, kind: string): Promise<any> {
+        return fetch(`corpus-output/${repo.Name}.${kind}.json.gz`)
+            .then(result => {
+                if (!result.ok) {
+                    throw new Error(`${result.status} ${result.statusText}`);
+                }
+                return result.arrayBuffer();
+            })
+            .then(b => JSON.parse(new TextDecoder("utf-8").decode(pako.ungzip(new Uint8Array(b)))))
+            .catch(error => {
+                console.warn(`${repo.Name}: can't load ${kind} index: ${error}`);
+                return null;
+            });
+    }
+
     function loadRecursive(toLoad: repositoryInfo[], onFinish) {
         if (toLoad.length == 0) {
             appState.busy = false;
@@ -336,13 +654,17 @@ namespace App {
                         }
                     }
                 }
-                console.log(`loaded ${repo.Name} repo`);
-                appState.corpus.set(repo.Name, repoData);
+                return Promise.all([loadIndex(repo, 'idents'), loadIndex(repo, 'decls')]).then(([idents, decls]) => {
+                    repoData.identIndex = idents;
+                    repoData.declIndex = decls;
+                    console.log(`loaded ${repo.Name} repo`);
+                    appState.corpus.set(repo.Name, repoData);
 
-                let $checkbox = <HTMLInputElement>(document.getElementById(`repository-${repo.Name}`));
-                $checkbox.parentElement.classList.add('blue-text');
+                    let $checkbox = <HTMLInputElement>(document.getElementById(`repository-${repo.Name}`));
+                    $checkbox.parentElement.classList.add('blue-text');
 
-                loadRecursive(toLoad, onFinish);
+                    loadRecursive(toLoad, onFinish);
+                });
             });
     }
 
@@ -402,7 +724,8 @@ namespace App {
                     }
                     var repo = appState.metadata.Repositories[index];
                     let sizeMB = (repo.MinifiedSize * 0.000001).toFixed(1);
-                    let hint = `Tags: [${repo.Tags}], Size: ${sizeMB} MB, Files: ${repo.Files.length}, SLOC: ${repo.SLOC.toLocaleString()}`;
+                    let goVersion = repo.GoVersion ? `, Go: ${repo.GoVersion}` : '';
+                    let hint = `Tags: [${repo.Tags}]${goVersion}, Size: ${sizeMB} MB, Files: ${repo.Files.length}, SLOC: ${repo.SLOC.toLocaleString()}`;
                     selectionHTML += `<td><label title="${hint}"><input id="repository-${repo.Name}" type="checkbox"> ${repo.Name}</label></td>`;
                 }
                 selectionHTML += '</tr>';
@@ -416,6 +739,40 @@ namespace App {
             loadRepositories();
         };
 
+        let $withComments = <HTMLInputElement>(document.getElementById('with-comments'));
+        $withComments.disabled = !appState.metadata.WithComments;
+        $withComments.onchange = function() {
+            if (appState.busy) {
+                $withComments.checked = appState.withComments;
+                return;
+            }
+            // The loaded sources are no longer valid for the selected mode.
+            appState.withComments = $withComments.checked;
+            appState.corpus.clear();
+            for (let repo of appState.metadata.Repositories) {
+                let $checkbox = <HTMLInputElement>(document.getElementById(`repository-${repo.Name}`));
+                $checkbox.parentElement.classList.remove('blue-text');
+            }
+        };
+
+        let $packageMode = <HTMLInputElement>(document.getElementById('package-mode'));
+        $packageMode.onchange = function() {
+            if (appState.busy) {
+                $packageMode.checked = appState.packageMode;
+                return;
+            }
+            appState.packageMode = $packageMode.checked;
+        };
+
+        let $declMode = <HTMLInputElement>(document.getElementById('decl-mode'));
+        $declMode.onchange = function() {
+            if (appState.busy) {
+                $declMode.checked = appState.declMode;
+                return;
+            }
+            appState.declMode = $declMode.checked;
+        };
+
         let $selectAll = document.getElementById('selectall-button');
         $selectAll.onclick = function() {
             let allSelected = allReposSelected();
@@ -454,17 +811,20 @@ namespace App {
             appState.slocProcessed = 0;
             appState.filesTotal = 0;
             appState.hits = 0;
+            appState.packagesScanned.clear();
+            appState.packagesMatched.clear();
             let repos = getSelectedRepos();
             for (let repo of repos) {
                 appState.filesTotal += repo.Files.length;
             }
+            appState.fileFlags = scannedFileFlags(repos);
             forcedLoadRepositories(() => {
                 let $progress = document.getElementById('search-progress');
                 $progress.innerHTML = '';
                 $run.innerText = 'Stop';
                 appState.running = true;
                 appState.runStartTime = window.performance.now();
-                runQueryRecursive(pattern, filter, repos);
+                runQueryRecursive(pattern, filter, gogrepPatternIdents(pattern), repos);
             });
         };
     }
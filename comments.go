@@ -0,0 +1,104 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// suppression is a parsed `//nolint` or `//lint:ignore` directive.
+type suppression struct {
+	// linters is a list of suppressed linters.
+	// An empty list means "all linters".
+	linters []string
+}
+
+func (s suppression) Suppresses(linter string) bool {
+	if linter == "" || len(s.linters) == 0 {
+		return true
+	}
+	for _, l := range s.linters {
+		if l == linter || l == "all" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSuppression parses the comment text as a linter suppression directive.
+//
+// The recognized forms are:
+//
+//	//nolint
+//	//nolint:name1,name2 // optional explanation
+//	//lint:ignore Name1,Name2 reason
+func parseSuppression(text string) (suppression, bool) {
+	if !strings.HasPrefix(text, "//") {
+		return suppression{}, false
+	}
+	text = strings.TrimSpace(text[len("//"):])
+
+	if strings.HasPrefix(text, "lint:ignore ") {
+		fields := strings.Fields(text[len("lint:ignore "):])
+		if len(fields) == 0 {
+			return suppression{}, false
+		}
+		return suppression{linters: strings.Split(fields[0], ",")}, true
+	}
+
+	if !strings.HasPrefix(text, "nolint") {
+		return suppression{}, false
+	}
+	rest := text[len("nolint"):]
+	switch {
+	case rest == "" || rest[0] == ' ' || rest[0] == '\t':
+		return suppression{}, true
+	case rest[0] == ':':
+		list := rest[1:]
+		if i := strings.IndexAny(list, " \t"); i != -1 {
+			list = list[:i]
+		}
+		if list == "" {
+			return suppression{}, false
+		}
+		return suppression{linters: strings.Split(list, ",")}, true
+	default:
+		// Something like `//nolintfoo`.
+		return suppression{}, false
+	}
+}
+
+// collectSuppressions returns all linter suppression directives
+// of the file, grouped by their line number.
+func collectSuppressions(fset *token.FileSet, f *ast.File) map[int][]suppression {
+	result := make(map[int][]suppression)
+	for _, group := range f.Comments {
+		for _, c := range group.List {
+			s, ok := parseSuppression(c.Text)
+			if !ok {
+				continue
+			}
+			line := fset.Position(c.Pos()).Line
+			result[line] = append(result[line], s)
+		}
+	}
+	return result
+}
+
+// nodeDoc returns the doc comment associated with n, if any.
+func nodeDoc(n ast.Node) *ast.CommentGroup {
+	switch n := n.(type) {
+	case *ast.FuncDecl:
+		return n.Doc
+	case *ast.GenDecl:
+		return n.Doc
+	case *ast.Field:
+		return n.Doc
+	case *ast.TypeSpec:
+		return n.Doc
+	case *ast.ValueSpec:
+		return n.Doc
+	default:
+		return nil
+	}
+}
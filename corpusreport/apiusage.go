@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/token"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/quasilyte/gocorpus/internal/imports"
+)
+
+type apiUsageStats struct {
+	uses  int
+	files int
+	repos map[string]struct{}
+}
+
+func (stats *apiUsageStats) add(repo string, uses int) {
+	stats.uses += uses
+	stats.files++
+	stats.repos[repo] = struct{}{}
+}
+
+// reportAPIUsage prints the imported packages usage
+// and the usage of their exported symbols.
+//
+// The symbols are the qualified identifiers like `strings.Cut`,
+// resolved through the file imports. Methods and the dot-imported
+// package symbols are not counted.
+//
+// If the -pkg pattern is set, only the matching packages are reported.
+// For the matching stdlib packages, the symbols that are never used
+// are listed in a separate table that is not limited by -top.
+func reportAPIUsage(ctx *context) {
+	packages := make(map[string]*apiUsageStats)
+	symbols := make(map[string]map[string]*apiUsageStats)
+
+	newStats := func() *apiUsageStats {
+		return &apiUsageStats{repos: make(map[string]struct{})}
+	}
+	matchPkg := func(path string) bool {
+		return ctx.pkgPattern == "" || imports.MatchPattern(ctx.pkgPattern, path)
+	}
+
+	for _, repo := range ctx.meta.Repositories {
+		err := walkRepoFiles(ctx, repo, func(f *repoFile) {
+			// fileImports maps the file imports to their symbol uses.
+			fileImports := make(map[string]int)
+			for _, imp := range f.ast.Imports {
+				path, err := strconv.Unquote(imp.Path.Value)
+				if err == nil && matchPkg(path) {
+					fileImports[path] = 0
+				}
+			}
+			fileSymbols := make(map[string]map[string]int)
+
+			table := imports.NewTable(f.ast)
+			ast.Inspect(f.ast, func(n ast.Node) bool {
+				sel, ok := n.(*ast.SelectorExpr)
+				if !ok || !sel.Sel.IsExported() {
+					return true
+				}
+				x, ok := sel.X.(*ast.Ident)
+				if !ok {
+					return true
+				}
+				path := table.PkgPath(x)
+				if _, ok := fileImports[path]; !ok {
+					return true
+				}
+				fileImports[path]++
+				if fileSymbols[path] == nil {
+					fileSymbols[path] = make(map[string]int)
+				}
+				fileSymbols[path][sel.Sel.Name]++
+				return true
+			})
+
+			for path, uses := range fileImports {
+				if packages[path] == nil {
+					packages[path] = newStats()
+					symbols[path] = make(map[string]*apiUsageStats)
+				}
+				packages[path].add(repo.Name, uses)
+				for name, uses := range fileSymbols[path] {
+					if symbols[path][name] == nil {
+						symbols[path][name] = newStats()
+					}
+					symbols[path][name].add(repo.Name, uses)
+				}
+			}
+		})
+		if err != nil {
+			log.Printf("%s: %v", repo.Name, err)
+		}
+	}
+
+	var unused map[string][]string
+	if ctx.pkgPattern != "" {
+		unused = findUnusedSymbols(ctx.pkgPattern, packages, symbols, newStats)
+	}
+
+	numRepos := len(ctx.meta.Repositories)
+
+	fmt.Fprintln(ctx.out, "# Packages")
+	w := tabwriter.NewWriter(ctx.out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "PACKAGE\tUSES\tFILES\tREPOS\tREPOS%")
+	for _, path := range sortAPIUsage(ctx, packages) {
+		stats := packages[path]
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%.2f%%\n", path, stats.uses, stats.files, len(stats.repos), repoShare(stats, numRepos))
+	}
+	w.Flush()
+
+	fmt.Fprintln(ctx.out, "\n# Symbols")
+	w = tabwriter.NewWriter(ctx.out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "PACKAGE\tSYMBOL\tUSES\tFILES\tREPOS\tREPOS%")
+	for _, path := range sortedKeys(symbols) {
+		for _, name := range sortAPIUsage(ctx, symbols[path]) {
+			stats := symbols[path][name]
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%.2f%%\n", path, name, stats.uses, stats.files, len(stats.repos), repoShare(stats, numRepos))
+		}
+	}
+	w.Flush()
+
+	if ctx.pkgPattern == "" {
+		return
+	}
+	fmt.Fprintln(ctx.out, "\n# Unused symbols")
+	w = tabwriter.NewWriter(ctx.out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "PACKAGE\tSYMBOL")
+	for _, path := range sortedKeys(unused) {
+		for _, name := range unused[path] {
+			fmt.Fprintf(w, "%s\t%s\n", path, name)
+		}
+	}
+	w.Flush()
+}
+
+// findUnusedSymbols returns the exported package-level symbols
+// of the stdlib packages that match the pattern and are never used.
+// The result maps a package path to the sorted symbol names.
+// The symbols are loaded from the GOROOT sources.
+//
+// The pattern path itself is checked even if no file imports it,
+// so the completely unused packages are reported too:
+// they are added to the packages with the zero stats.
+func findUnusedSymbols(pattern string, packages map[string]*apiUsageStats, symbols map[string]map[string]*apiUsageStats, newStats func() *apiUsageStats) map[string][]string {
+	unused := make(map[string][]string)
+	paths := sortedKeys(symbols)
+	if prefix := strings.TrimSuffix(pattern, "/..."); symbols[prefix] == nil {
+		paths = append(paths, prefix)
+	}
+
+	imp := importer.ForCompiler(token.NewFileSet(), "source", nil)
+	for _, path := range paths {
+		if !imports.MatchPattern(pattern, path) || !isStdlibPath(path) {
+			continue
+		}
+		pkg, err := imp.Import(path)
+		if err != nil {
+			log.Printf("can't list %s symbols: %v", path, err)
+			continue
+		}
+		if packages[path] == nil {
+			packages[path] = newStats()
+			symbols[path] = make(map[string]*apiUsageStats)
+		}
+		// Names are sorted.
+		for _, name := range pkg.Scope().Names() {
+			if token.IsExported(name) && symbols[path][name] == nil {
+				unused[path] = append(unused[path], name)
+			}
+		}
+	}
+	return unused
+}
+
+// sortAPIUsage returns the keys of m in the -sort order,
+// limited by the -top value.
+func sortAPIUsage(ctx *context, m map[string]*apiUsageStats) []string {
+	keys := sortedKeys(m)
+	switch ctx.sortBy {
+	case "uses":
+		sort.SliceStable(keys, func(i, j int) bool {
+			return m[keys[i]].uses > m[keys[j]].uses
+		})
+	case "repos":
+		sort.SliceStable(keys, func(i, j int) bool {
+			return len(m[keys[i]].repos) > len(m[keys[j]].repos)
+		})
+	}
+	if ctx.top > 0 && len(keys) > ctx.top {
+		keys = keys[:ctx.top]
+	}
+	return keys
+}
+
+func repoShare(stats *apiUsageStats, numRepos int) float64 {
+	if numRepos == 0 {
+		return 0
+	}
+	return 100 * float64(len(stats.repos)) / float64(numRepos)
+}
+
+// isStdlibPath reports whether the import path looks like a stdlib package:
+// the first path element of the other packages is a domain name.
+func isStdlibPath(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
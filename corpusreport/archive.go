@@ -0,0 +1,87 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/quasilyte/gocorpus/internal/filebits"
+)
+
+// repoFile is a parsed repository file.
+type repoFile struct {
+	meta *fileMeta
+	fset *token.FileSet
+	ast  *ast.File
+}
+
+// walkRepoFiles calls fn for every repository file from its sources archive.
+//
+// The archive is <repo>.tar.gz (or <repo>.tar if the corpus was built with
+// the compression disabled). The files are parsed from the minified sources,
+// so they have no comments and the positions don't match the originals.
+//
+// Duplicated files are skipped, so they don't inflate the stats.
+func walkRepoFiles(ctx *context, repo *repositoryMeta, fn func(f *repoFile)) error {
+	r, closeArchive, err := openRepoArchive(ctx, repo)
+	if err != nil {
+		return err
+	}
+	defer closeArchive()
+
+	// The archive files are written in the repositoryMeta.Files order.
+	tr := tar.NewReader(r)
+	for i := 0; ; i++ {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if i >= len(repo.Files) {
+			return fmt.Errorf("%s: unexpected archive file %s", repo.Name, hdr.Name)
+		}
+		meta := repo.Files[i]
+		if hdr.Name != repo.Name+"/"+meta.Name {
+			return fmt.Errorf("%s: files[%d] name mismatch: %s vs %s", repo.Name, i, hdr.Name, meta.Name)
+		}
+		if meta.Flags&filebits.IsDuplicate != 0 {
+			continue
+		}
+		src, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, hdr.Name, src, 0)
+		if err != nil {
+			return err
+		}
+		fn(&repoFile{meta: meta, fset: fset, ast: f})
+	}
+	return nil
+}
+
+func openRepoArchive(ctx *context, repo *repositoryMeta) (io.Reader, func(), error) {
+	base := filepath.Join(ctx.corpusDir, repo.Name)
+	if f, err := os.Open(base + ".tar.gz"); err == nil {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		return gz, func() { f.Close() }, nil
+	}
+	f, err := os.Open(base + ".tar")
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, func() { f.Close() }, nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// These types describe the parts of corpus.json that the reports need.
+// See makecorpus/metadata.go for the complete format description.
+
+type corpusMeta struct {
+	Version      int
+	WithComments bool
+	Repositories []*repositoryMeta
+}
+
+type repositoryMeta struct {
+	Name  string
+	Tags  []string
+	SLOC  int
+	Files []*fileMeta
+}
+
+type fileMeta struct {
+	Name       string
+	Flags      int
+	SLOC       int
+	Directives map[string]int
+}
+
+func loadCorpusMeta(filename string) (*corpusMeta, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var meta corpusMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
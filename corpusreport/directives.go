@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"text/tabwriter"
+)
+
+type directiveStats struct {
+	count int // Total number of occurrences
+	files int // Number of files that use the directive
+}
+
+// reportDirectives prints the //go: directives usage grouped
+// by the repositories and by the repository tags.
+func reportDirectives(ctx *context) {
+	byRepo := make(map[string]map[string]*directiveStats)
+	byTag := make(map[string]map[string]*directiveStats)
+	total := make(map[string]*directiveStats)
+
+	add := func(m map[string]*directiveStats, name string, count int) {
+		stats := m[name]
+		if stats == nil {
+			stats = &directiveStats{}
+			m[name] = stats
+		}
+		stats.count += count
+		stats.files++
+	}
+	group := func(m map[string]map[string]*directiveStats, key string) map[string]*directiveStats {
+		g := m[key]
+		if g == nil {
+			g = make(map[string]*directiveStats)
+			m[key] = g
+		}
+		return g
+	}
+
+	for _, repo := range ctx.meta.Repositories {
+		for _, f := range repo.Files {
+			for name, count := range f.Directives {
+				add(total, name, count)
+				add(group(byRepo, repo.Name), name, count)
+				for _, tag := range repo.Tags {
+					add(group(byTag, tag), name, count)
+				}
+			}
+		}
+	}
+
+	fmt.Fprintln(ctx.out, "# Total")
+	printDirectiveStats(ctx, map[string]map[string]*directiveStats{"corpus": total})
+	fmt.Fprintln(ctx.out, "\n# By repository")
+	printDirectiveStats(ctx, byRepo)
+	fmt.Fprintln(ctx.out, "\n# By tag")
+	printDirectiveStats(ctx, byTag)
+}
+
+func printDirectiveStats(ctx *context, groups map[string]map[string]*directiveStats) {
+	w := tabwriter.NewWriter(ctx.out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "GROUP\tDIRECTIVE\tCOUNT\tFILES")
+	for _, key := range sortedKeys(groups) {
+		g := groups[key]
+		names := sortedKeys(g)
+		sort.SliceStable(names, func(i, j int) bool {
+			return g[names[i]].count > g[names[j]].count
+		})
+		for _, name := range names {
+			fmt.Fprintf(w, "%s\t//go:%s\t%d\t%d\n", key, name, g[name].count, g[name].files)
+		}
+	}
+	w.Flush()
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+func main() {
+	log.SetFlags(0)
+
+	reportModes := map[string]func(*context){
+		"apiusage":   reportAPIUsage,
+		"directives": reportDirectives,
+		"naming":     reportNaming,
+		"structtags": reportStructTags,
+	}
+
+	ctx := &context{}
+	corpusDir := flag.String("i", "corpus-output", "the corpus directory produced by the makecorpus")
+	mode := flag.String("mode", "", "the report mode: "+strings.Join(sortedKeys(reportModes), ", "))
+	top := flag.Int("top", 10, "the max number of rows per table group, 0 means no limit")
+	pkgPattern := flag.String("pkg", "", "the import path pattern (like net/...) to limit the apiusage report; the never used stdlib symbols are only listed if it's set")
+	sortBy := flag.String("sort", "uses", "the apiusage report order: uses, repos or name")
+	flag.Parse()
+
+	report, ok := reportModes[*mode]
+	if !ok {
+		log.Fatalf("unknown -mode=%q", *mode)
+	}
+	switch *sortBy {
+	case "uses", "repos", "name":
+	default:
+		log.Fatalf("unknown -sort=%q", *sortBy)
+	}
+
+	ctx.corpusDir = *corpusDir
+	ctx.top = *top
+	ctx.pkgPattern = *pkgPattern
+	ctx.sortBy = *sortBy
+	meta, err := loadCorpusMeta(filepath.Join(ctx.corpusDir, "corpus.json"))
+	if err != nil {
+		log.Fatalf("load corpus metadata: %v", err)
+	}
+	ctx.meta = meta
+	ctx.out = os.Stdout
+
+	report(ctx)
+}
+
+type context struct {
+	corpusDir string
+	top       int
+
+	// pkgPattern and sortBy are the apiusage report options.
+	pkgPattern string
+	sortBy     string
+
+	meta *corpusMeta
+	out  *os.File
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,458 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"log"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+)
+
+// Naming roles, in the report order.
+const (
+	roleReceiver      = "receiver"
+	roleErrorVar      = "error var"
+	roleSentinelError = "sentinel error"
+	roleRangeKey      = "range key"
+	roleRangeValue    = "range value"
+	roleLoopCounter   = "loop counter"
+	roleImportAlias   = "import alias"
+	roleGetter        = "getter"
+	roleInitialism    = "initialism"
+)
+
+var namingRoles = []string{
+	roleReceiver,
+	roleErrorVar,
+	roleSentinelError,
+	roleRangeKey,
+	roleRangeValue,
+	roleLoopCounter,
+	roleImportAlias,
+	roleGetter,
+	roleInitialism,
+}
+
+// namingStats maps a role to its names usage counters.
+type namingStats map[string]map[string]int
+
+func (stats namingStats) add(role, name string) {
+	m := stats[role]
+	if m == nil {
+		m = make(map[string]int)
+		stats[role] = m
+	}
+	m[name]++
+}
+
+// reportNaming prints the identifier names distributions by their roles.
+// Only the declaration sites are inspected, so every
+// declared name is counted once regardless of its uses.
+func reportNaming(ctx *context) {
+	total := namingStats{}
+	byRepo := make(map[string]namingStats)
+	byTag := make(map[string]namingStats)
+
+	for _, repo := range ctx.meta.Repositories {
+		groups := []namingStats{total, namingStatsGroup(byRepo, repo.Name)}
+		for _, tag := range repo.Tags {
+			groups = append(groups, namingStatsGroup(byTag, tag))
+		}
+		err := walkRepoFiles(ctx, repo, func(f *repoFile) {
+			collectNames(f.ast, func(role, name string) {
+				for _, g := range groups {
+					g.add(role, name)
+				}
+			})
+		})
+		if err != nil {
+			log.Printf("%s: %v", repo.Name, err)
+		}
+	}
+
+	fmt.Fprintln(ctx.out, "# Total")
+	printNamingStats(ctx, map[string]namingStats{"corpus": total})
+	fmt.Fprintln(ctx.out, "\n# By repository")
+	printNamingStats(ctx, byRepo)
+	fmt.Fprintln(ctx.out, "\n# By tag")
+	printNamingStats(ctx, byTag)
+}
+
+func namingStatsGroup(m map[string]namingStats, key string) namingStats {
+	g := m[key]
+	if g == nil {
+		g = namingStats{}
+		m[key] = g
+	}
+	return g
+}
+
+func printNamingStats(ctx *context, groups map[string]namingStats) {
+	w := tabwriter.NewWriter(ctx.out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "GROUP\tROLE\tNAME\tCOUNT\tSHARE")
+	for _, key := range sortedKeys(groups) {
+		for _, role := range namingRoles {
+			m := groups[key][role]
+			total := 0
+			for _, count := range m {
+				total += count
+			}
+			names := sortedKeys(m)
+			sort.SliceStable(names, func(i, j int) bool {
+				return m[names[i]] > m[names[j]]
+			})
+			if ctx.top > 0 && len(names) > ctx.top {
+				names = names[:ctx.top]
+			}
+			for _, name := range names {
+				share := 100 * float64(m[name]) / float64(total)
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%.2f%%\n", key, role, name, m[name], share)
+			}
+		}
+	}
+	w.Flush()
+}
+
+// collectNames calls add for every name declaration that has a known role.
+//
+// Error variables are recognized syntactically: they're either declared
+// with an explicit error type or checked with `if x != nil` right after
+// the `x := ...` declaration (or inside the if statement init clause).
+// See checkedErrorVar for the details.
+func collectNames(f *ast.File, add func(role, name string)) {
+	for _, imp := range f.Imports {
+		if imp.Name != nil && imp.Name.Name != "_" && imp.Name.Name != "." {
+			add(roleImportAlias, imp.Name.Name+" "+imp.Path.Value)
+		}
+	}
+
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv != nil && len(decl.Recv.List) != 0 {
+				recv := decl.Recv.List[0]
+				if len(recv.Names) == 0 || recv.Names[0].Name == "_" {
+					add(roleReceiver, "(unnamed)")
+				} else {
+					add(roleReceiver, recv.Names[0].Name)
+				}
+				if style := getterStyle(decl); style != "" {
+					add(roleGetter, style)
+				}
+			}
+		case *ast.GenDecl:
+			if decl.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				spec := spec.(*ast.ValueSpec)
+				for i, name := range spec.Names {
+					if i < len(spec.Values) && isErrorConstructorCall(spec.Values[i]) {
+						add(roleSentinelError, sentinelErrorStyle(name.Name))
+					}
+				}
+			}
+		}
+	}
+
+	addDecl := func(ident *ast.Ident) {
+		if ident.Name == "_" {
+			return
+		}
+		for _, word := range splitWords(ident.Name) {
+			if initialism, ok := initialismWord(word); ok {
+				add(roleInitialism, initialism)
+			}
+		}
+	}
+	addErrorVar := func(ident *ast.Ident) {
+		if ident.Name != "_" {
+			add(roleErrorVar, ident.Name)
+		}
+	}
+	// addCheckedErrorVars handles the `x := f(); if x != nil {...}` statement pairs.
+	addCheckedErrorVars := func(list []ast.Stmt) {
+		for i := 0; i+1 < len(list); i++ {
+			ifStmt, ok := list[i+1].(*ast.IfStmt)
+			if !ok || ifStmt.Init != nil {
+				continue
+			}
+			if ident := checkedErrorVar(list[i], ifStmt); ident != nil {
+				addErrorVar(ident)
+			}
+		}
+	}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			addDecl(n.Name)
+		case *ast.TypeSpec:
+			addDecl(n.Name)
+		case *ast.ValueSpec:
+			for _, name := range n.Names {
+				addDecl(name)
+				if isErrorType(n.Type) {
+					addErrorVar(name)
+				}
+			}
+		case *ast.Field:
+			for _, name := range n.Names {
+				addDecl(name)
+				if isErrorType(n.Type) {
+					addErrorVar(name)
+				}
+			}
+		case *ast.AssignStmt:
+			if n.Tok == token.DEFINE {
+				for _, lhs := range n.Lhs {
+					if ident, ok := lhs.(*ast.Ident); ok {
+						addDecl(ident)
+					}
+				}
+			}
+		case *ast.RangeStmt:
+			if n.Tok == token.DEFINE {
+				if ident, ok := n.Key.(*ast.Ident); ok && ident.Name != "_" {
+					add(roleRangeKey, ident.Name)
+				}
+				if ident, ok := n.Value.(*ast.Ident); ok && ident.Name != "_" {
+					add(roleRangeValue, ident.Name)
+				}
+			}
+		case *ast.ForStmt:
+			if init, ok := n.Init.(*ast.AssignStmt); ok && init.Tok == token.DEFINE && len(init.Lhs) == 1 {
+				if ident, ok := init.Lhs[0].(*ast.Ident); ok && ident.Name != "_" {
+					add(roleLoopCounter, ident.Name)
+				}
+			}
+		case *ast.IfStmt:
+			if ident := checkedErrorVar(n.Init, n); ident != nil {
+				addErrorVar(ident)
+			}
+		case *ast.BlockStmt:
+			addCheckedErrorVars(n.List)
+		case *ast.CaseClause:
+			addCheckedErrorVars(n.Body)
+		case *ast.CommClause:
+			addCheckedErrorVars(n.Body)
+		}
+		return true
+	})
+}
+
+// checkedErrorVar returns the last variable defined by the stmt
+// if the ifStmt condition is a `x != nil` check of that variable.
+//
+// Not every nil-checked variable is an error, so a single variable
+// definition is only accepted if the if statement body reports it:
+// returns it or passes it to some function (like t.Fatal(err) does).
+// For the multi-value definitions the last value
+// is expected to be an error by the convention.
+func checkedErrorVar(stmt ast.Stmt, ifStmt *ast.IfStmt) *ast.Ident {
+	assign, ok := stmt.(*ast.AssignStmt)
+	if !ok || assign.Tok != token.DEFINE {
+		return nil
+	}
+	last, ok := assign.Lhs[len(assign.Lhs)-1].(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	binary, ok := ifStmt.Cond.(*ast.BinaryExpr)
+	if !ok || binary.Op != token.NEQ {
+		return nil
+	}
+	x, ok := binary.X.(*ast.Ident)
+	if !ok || x.Name != last.Name {
+		return nil
+	}
+	y, ok := binary.Y.(*ast.Ident)
+	if !ok || y.Name != "nil" {
+		return nil
+	}
+	if len(assign.Lhs) == 1 && !reportsVar(ifStmt.Body, last.Name) {
+		return nil
+	}
+	return last
+}
+
+// reportsVar reports whether the body returns the named variable
+// or passes it as a function argument.
+func reportsVar(body *ast.BlockStmt, name string) bool {
+	found := false
+	mentions := func(exprs []ast.Expr) {
+		for _, e := range exprs {
+			ast.Inspect(e, func(n ast.Node) bool {
+				if ident, ok := n.(*ast.Ident); ok && ident.Name == name {
+					found = true
+				}
+				return !found
+			})
+		}
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			mentions(n.Results)
+		case *ast.CallExpr:
+			mentions(n.Args)
+		}
+		return !found
+	})
+	return found
+}
+
+func isErrorType(e ast.Expr) bool {
+	ident, ok := e.(*ast.Ident)
+	return ok && ident.Name == "error"
+}
+
+func isErrorConstructorCall(e ast.Expr) bool {
+	call, ok := e.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	selector, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	pkg, ok := selector.X.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	return (pkg.Name == "errors" && selector.Sel.Name == "New") ||
+		(pkg.Name == "fmt" && selector.Sel.Name == "Errorf")
+}
+
+func sentinelErrorStyle(name string) string {
+	switch {
+	case hasWordPrefix(name, "Err"):
+		return "ErrFoo"
+	case hasWordPrefix(name, "err"):
+		return "errFoo"
+	case strings.HasSuffix(name, "Error"):
+		return "FooError"
+	default:
+		return "other"
+	}
+}
+
+// getterStyle classifies the exported methods that look like getters:
+// they have no params and a single result.
+// It returns an empty string for other methods.
+func getterStyle(decl *ast.FuncDecl) string {
+	typ := decl.Type
+	if !decl.Name.IsExported() || typ.Params.NumFields() != 0 || typ.Results.NumFields() != 1 {
+		return ""
+	}
+	name := decl.Name.Name
+	switch {
+	case hasWordPrefix(name, "Get"):
+		return "GetFoo"
+	case hasWordPrefix(name, "Is"), hasWordPrefix(name, "Has"), hasWordPrefix(name, "Can"):
+		return "IsFoo"
+	default:
+		return "Foo"
+	}
+}
+
+// hasWordPrefix reports whether the name starts with the prefix
+// that is followed by another word, like "Get" in "GetName".
+func hasWordPrefix(name, prefix string) bool {
+	if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+		return false
+	}
+	next := rune(name[len(prefix)])
+	return unicode.IsUpper(next) || unicode.IsDigit(next) || next == '_'
+}
+
+// splitWords splits a mixed caps identifier into its words.
+// The uppercase runs are kept together: "HTTPServer" is "HTTP" and "Server".
+// A trailing "s" after an uppercase run is a plural: "UserIDs" is "User" and "IDs".
+func splitWords(name string) []string {
+	var words []string
+	runes := []rune(name)
+	start := 0
+	for i := 1; i <= len(runes); i++ {
+		if i < len(runes) {
+			prev, cur := runes[i-1], runes[i]
+			boundary := cur == '_' || prev == '_' ||
+				(unicode.IsUpper(cur) && !unicode.IsUpper(prev)) ||
+				(unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) && !isPluralSuffix(runes, i+1))
+			if !boundary {
+				continue
+			}
+		}
+		if word := string(runes[start:i]); word != "_" {
+			words = append(words, word)
+		}
+		start = i
+	}
+	return words
+}
+
+// isPluralSuffix reports whether runes[i] is an "s" that ends a word.
+func isPluralSuffix(runes []rune, i int) bool {
+	return runes[i] == 's' && (i+1 == len(runes) || !unicode.IsLower(runes[i+1]))
+}
+
+// initialismWord returns the initialism spelling used by the word.
+// The plurals are counted as their singular forms, so "IDs" is "ID"
+// and "Ids" is "Id".
+func initialismWord(word string) (string, bool) {
+	if commonInitialisms[strings.ToUpper(word)] {
+		return word, true
+	}
+	if singular := strings.TrimSuffix(word, "s"); singular != word && commonInitialisms[strings.ToUpper(singular)] {
+		return singular, true
+	}
+	return "", false
+}
+
+// commonInitialisms is a golint list of the initialisms
+// that are expected to have a consistent case.
+var commonInitialisms = map[string]bool{
+	"ACL":   true,
+	"API":   true,
+	"ASCII": true,
+	"CPU":   true,
+	"CSS":   true,
+	"DNS":   true,
+	"EOF":   true,
+	"GUID":  true,
+	"HTML":  true,
+	"HTTP":  true,
+	"HTTPS": true,
+	"ID":    true,
+	"IP":    true,
+	"JSON":  true,
+	"LHS":   true,
+	"QPS":   true,
+	"RAM":   true,
+	"RHS":   true,
+	"RPC":   true,
+	"SLA":   true,
+	"SMTP":  true,
+	"SQL":   true,
+	"SSH":   true,
+	"TCP":   true,
+	"TLS":   true,
+	"TTL":   true,
+	"UDP":   true,
+	"UI":    true,
+	"UID":   true,
+	"UUID":  true,
+	"URI":   true,
+	"URL":   true,
+	"UTF8":  true,
+	"VM":    true,
+	"XML":   true,
+	"XMPP":  true,
+	"XSRF":  true,
+	"XSS":   true,
+}
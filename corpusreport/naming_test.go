@@ -0,0 +1,146 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"x", []string{"x"}},
+		{"userID", []string{"user", "ID"}},
+		{"UserId", []string{"User", "Id"}},
+		{"HTTPServer", []string{"HTTP", "Server"}},
+		{"ServeHTTP", []string{"Serve", "HTTP"}},
+		{"UserIDs", []string{"User", "IDs"}},
+		{"URLs", []string{"URLs"}},
+		{"IDsByName", []string{"IDs", "By", "Name"}},
+		{"HTTPStatus", []string{"HTTP", "Status"}},
+		{"HTTPSession", []string{"HTTP", "Session"}},
+		{"URLsToFetch", []string{"URLs", "To", "Fetch"}},
+		{"max_retry_count", []string{"max", "retry", "count"}},
+		{"_x", []string{"x"}},
+	}
+
+	for _, test := range tests {
+		have := splitWords(test.name)
+		if !reflect.DeepEqual(have, test.want) {
+			t.Errorf("splitWords(%q):\nhave %q\nwant %q", test.name, have, test.want)
+		}
+	}
+}
+
+func TestInitialismWord(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"ID", "ID"},
+		{"Id", "Id"},
+		{"id", "id"},
+		{"IDs", "ID"},
+		{"Ids", "Id"},
+		{"URLs", "URL"},
+		{"HTTPS", "HTTPS"},
+		{"Https", "Https"},
+		{"QPS", "QPS"},
+		{"User", ""},
+		{"Users", ""},
+	}
+
+	for _, test := range tests {
+		have, ok := initialismWord(test.word)
+		if ok != (test.want != "") || have != test.want {
+			t.Errorf("initialismWord(%q): have %q, %v; want %q", test.word, have, ok, test.want)
+		}
+	}
+}
+
+func TestCheckedErrorVar(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		// The multi-value definitions are accepted by the convention.
+		{`x, err := f(); if err != nil { log.Print(x) }`, "err"},
+		{`_, e := f(); if e != nil {}`, "e"},
+
+		// A single value should be reported by the if body.
+		{`err := f(); if err != nil { return err }`, "err"},
+		{`err := f(); if err != nil { return fmt.Errorf("f: %w", err) }`, "err"},
+		{`err := f(); if err != nil { t.Fatal(err) }`, "err"},
+		{`fn := f(); if fn != nil { fn() }`, ""},
+		{`p := f(); if p != nil { return nil }`, ""},
+		{`err := f(); if err != nil { go func() { log.Print(err) }() }`, ""},
+
+		// Not a nil check of the defined variable.
+		{`x, err := f(); if x != nil { return err }`, ""},
+		{`x, err := f(); if err == nil { return x }`, ""},
+		{`x, err := f(); if nil != err { return err }`, ""},
+		{`x, err = f(); if err != nil { return err }`, ""},
+		{`x, err := f(); if err != nil && x != nil { return err }`, ""},
+	}
+
+	for _, test := range tests {
+		src := "package p\nfunc _() {\n" + test.src + "\n}"
+		f, err := parser.ParseFile(token.NewFileSet(), "test.go", src, 0)
+		if err != nil {
+			t.Fatalf("parse %q: %v", test.src, err)
+		}
+		body := f.Decls[0].(*ast.FuncDecl).Body.List
+		have := ""
+		if ident := checkedErrorVar(body[0], body[1].(*ast.IfStmt)); ident != nil {
+			have = ident.Name
+		}
+		if have != test.want {
+			t.Errorf("%s:\nhave %q\nwant %q", test.src, have, test.want)
+		}
+	}
+}
+
+func TestCollectErrorVars(t *testing.T) {
+	const src = `package p
+func _() {
+	err := f()
+	if err != nil {
+		return err
+	}
+	switch {
+	case true:
+		e1 := f()
+		if e1 != nil {
+			return e1
+		}
+	}
+	select {
+	case <-ch:
+		_, e2 := f()
+		if e2 != nil {
+		}
+	}
+	if e3 := f(); e3 != nil {
+		return e3
+	}
+}
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "test.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var have []string
+	collectNames(f, func(role, name string) {
+		if role == roleErrorVar {
+			have = append(have, name)
+		}
+	})
+	want := []string{"err", "e1", "e2", "e3"}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("error vars mismatch:\nhave %v\nwant %v", have, want)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+
+	"github.com/quasilyte/gocorpus/internal/structtag"
+)
+
+type structTagKeyStats struct {
+	uses    int
+	repos   map[string]struct{}
+	options map[string]int
+	styles  map[string]int
+}
+
+// reportStructTags prints the struct tag keys usage, their options
+// and the naming styles of the tag values.
+func reportStructTags(ctx *context) {
+	keys := make(map[string]*structTagKeyStats)
+	numFields := 0
+	numTagged := 0
+
+	for _, repo := range ctx.meta.Repositories {
+		err := walkRepoFiles(ctx, repo, func(f *repoFile) {
+			ast.Inspect(f.ast, func(n ast.Node) bool {
+				st, ok := n.(*ast.StructType)
+				if !ok {
+					return true
+				}
+				for _, field := range st.Fields.List {
+					numFields++
+					if field.Tag == nil {
+						continue
+					}
+					tag, err := strconv.Unquote(field.Tag.Value)
+					if err != nil {
+						continue
+					}
+					numTagged++
+					for _, pair := range structtag.Parse(tag) {
+						stats := keys[pair.Key]
+						if stats == nil {
+							stats = &structTagKeyStats{
+								repos:   make(map[string]struct{}),
+								options: make(map[string]int),
+								styles:  make(map[string]int),
+							}
+							keys[pair.Key] = stats
+						}
+						stats.uses++
+						stats.repos[repo.Name] = struct{}{}
+						name, options := structtag.Split(pair.Value)
+						for _, opt := range options {
+							stats.options[opt]++
+						}
+						stats.styles[tagNameStyle(fieldName(field), name)]++
+					}
+				}
+				return true
+			})
+		})
+		if err != nil {
+			log.Printf("%s: %v", repo.Name, err)
+		}
+	}
+
+	names := sortedKeys(keys)
+	sort.SliceStable(names, func(i, j int) bool {
+		return keys[names[i]].uses > keys[names[j]].uses
+	})
+
+	fmt.Fprintf(ctx.out, "# Keys (%d of %d fields are tagged)\n", numTagged, numFields)
+	w := tabwriter.NewWriter(ctx.out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tUSES\tREPOS")
+	for _, key := range names {
+		fmt.Fprintf(w, "%s\t%d\t%d\n", key, keys[key].uses, len(keys[key].repos))
+	}
+	w.Flush()
+
+	fmt.Fprintln(ctx.out, "\n# Options")
+	printStructTagShares(ctx, "OPTION", keys, names, func(stats *structTagKeyStats) map[string]int {
+		return stats.options
+	})
+
+	fmt.Fprintln(ctx.out, "\n# Name styles")
+	printStructTagShares(ctx, "STYLE", keys, names, func(stats *structTagKeyStats) map[string]int {
+		return stats.styles
+	})
+}
+
+// printStructTagShares prints the per-key counters along with
+// their share of the key uses.
+func printStructTagShares(ctx *context, column string, keys map[string]*structTagKeyStats, names []string, get func(*structTagKeyStats) map[string]int) {
+	w := tabwriter.NewWriter(ctx.out, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "KEY\t%s\tUSES\tSHARE\n", column)
+	for _, key := range names {
+		m := get(keys[key])
+		values := sortedKeys(m)
+		sort.SliceStable(values, func(i, j int) bool {
+			return m[values[i]] > m[values[j]]
+		})
+		if ctx.top > 0 && len(values) > ctx.top {
+			values = values[:ctx.top]
+		}
+		for _, v := range values {
+			share := 100 * float64(m[v]) / float64(keys[key].uses)
+			fmt.Fprintf(w, "%s\t%s\t%d\t%.2f%%\n", key, v, m[v], share)
+		}
+	}
+	w.Flush()
+}
+
+// fieldName returns the Go name of the field.
+// For the embedded fields, it's a type name.
+func fieldName(field *ast.Field) string {
+	if len(field.Names) != 0 {
+		return field.Names[0].Name
+	}
+	typ := field.Type
+	for {
+		switch x := typ.(type) {
+		case *ast.StarExpr:
+			typ = x.X
+		case *ast.SelectorExpr:
+			return x.Sel.Name
+		case *ast.IndexExpr:
+			typ = x.X
+		case *ast.IndexListExpr:
+			typ = x.X
+		case *ast.Ident:
+			return x.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// tagNameStyle classifies the name part of the tag value.
+func tagNameStyle(fieldName, name string) string {
+	switch {
+	case name == "":
+		return "(default)"
+	case name == "-":
+		return "(ignored)"
+	case name == fieldName:
+		return "FieldName"
+	case name == strings.ToLower(fieldName):
+		return "lowercase"
+	case strings.Contains(name, "_"):
+		return "snake_case"
+	case strings.Contains(name, "-"):
+		return "kebab-case"
+	case unicode.IsLower(rune(name[0])) && strings.ToLower(name) != name:
+		return "camelCase"
+	case strings.ToLower(name) == name:
+		return "lowercase"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"syscall/js"
+
+	"github.com/quasilyte/gocorpus/internal/filters"
+)
+
+// declInfo is a <repo>.decls.json record.
+// See makecorpus/decl_index.go for the format description.
+type declInfo struct {
+	props    map[string]string
+	exported bool
+}
+
+func readDeclInfo(o js.Value) declInfo {
+	d := declInfo{
+		props:    make(map[string]string, 5),
+		exported: o.Get("Exported").Truthy(),
+	}
+	for _, key := range []string{"Kind", "Name", "Receiver", "TypeKind", "Signature"} {
+		// Receiver and TypeKind are omitted if they're empty.
+		if v := o.Get(key); v.Type() == js.TypeString {
+			d.props[key] = v.String()
+		}
+	}
+	return d
+}
+
+// jsGogrepDecls runs the filter over the file declarations index.
+// There is no pattern: every declaration that satisfies
+// the filter is a match, its signature is reported as the match text.
+//
+// The args object has the same file fields as the gogrep args
+// (except the target source) plus the decls array.
+func jsGogrepDecls(this js.Value, args []js.Value) interface{} {
+	argsObject := args[0]
+	filterString := argsObject.Get("filter").String()
+	declsArray := argsObject.Get("decls")
+	file := readFileArgs(argsObject)
+
+	filterExpr, filterInfo, err := filters.CompileExpr(filterString)
+	if err != nil {
+		return map[string]interface{}{"err": "filter: " + err.Error()}
+	}
+	if err := checkDeclFilter(filterExpr); err != nil {
+		return map[string]interface{}{"err": "filter: " + err.Error()}
+	}
+
+	if result := checkFileFilters(&filterInfo, file); result != nil {
+		return result
+	}
+
+	regexps := make(map[string]*regexp.Regexp)
+	var matches []interface{}
+	for i := 0; i < declsArray.Length(); i++ {
+		d := readDeclInfo(declsArray.Index(i))
+		if filterExpr.Op == filters.OpNop || applyDeclFilter(regexps, filterExpr, d) {
+			matches = append(matches, d.props["Signature"])
+		}
+	}
+	return map[string]interface{}{
+		"matches": matches,
+		"skipped": false,
+	}
+}
+
+// checkDeclFilter reports an error if the filter uses
+// something that is not available in the decl search mode,
+// like the pattern vars or match object.
+func checkDeclFilter(e *filters.Expr) error {
+	switch e.Op {
+	case filters.OpNop, filters.OpNot, filters.OpAnd, filters.OpOr:
+		for _, arg := range e.Args {
+			if err := checkDeclFilter(arg); err != nil {
+				return err
+			}
+		}
+		return nil
+	case filters.OpDeclKindIs, filters.OpDeclIsExported, filters.OpDeclPropEq, filters.OpDeclPropMatches:
+		return nil
+	default:
+		return fmt.Errorf("%s can't be used in the decl search mode", e.Op)
+	}
+}
+
+func applyDeclFilter(regexps map[string]*regexp.Regexp, f *filters.Expr, d declInfo) bool {
+	switch f.Op {
+	case filters.OpNot:
+		return !applyDeclFilter(regexps, f.Args[0], d)
+	case filters.OpAnd:
+		return applyDeclFilter(regexps, f.Args[0], d) && applyDeclFilter(regexps, f.Args[1], d)
+	case filters.OpOr:
+		return applyDeclFilter(regexps, f.Args[0], d) || applyDeclFilter(regexps, f.Args[1], d)
+
+	case filters.OpDeclKindIs:
+		return d.props["Kind"] == f.Value
+	case filters.OpDeclIsExported:
+		return d.exported
+	case filters.OpDeclPropEq:
+		return d.props[f.Str] == f.Value
+	case filters.OpDeclPropMatches:
+		re, ok := regexps[f.Value]
+		if !ok {
+			// The pattern is validated during the filter compilation.
+			re = regexp.MustCompile(f.Value)
+			regexps[f.Value] = re
+		}
+		return re.MatchString(d.props[f.Str])
+
+	default:
+		return true
+	}
+}
@@ -0,0 +1,179 @@
+// Package buildtags evaluates the file build constraints without go/build.
+//
+// The corpus files are stored outside of their original build context,
+// so we can't rely on go/build.Context; the constraint expression and
+// the filename-implied tags are recorded by the makecorpus instead.
+package buildtags
+
+import (
+	"go/build/constraint"
+	"strings"
+)
+
+// knownOS and knownArch follow the go/build syslist.go lists.
+
+var knownOS = map[string]bool{
+	"aix":       true,
+	"android":   true,
+	"darwin":    true,
+	"dragonfly": true,
+	"freebsd":   true,
+	"hurd":      true,
+	"illumos":   true,
+	"ios":       true,
+	"js":        true,
+	"linux":     true,
+	"nacl":      true,
+	"netbsd":    true,
+	"openbsd":   true,
+	"plan9":     true,
+	"solaris":   true,
+	"wasip1":    true,
+	"windows":   true,
+	"zos":       true,
+}
+
+var unixOS = map[string]bool{
+	"aix":       true,
+	"android":   true,
+	"darwin":    true,
+	"dragonfly": true,
+	"freebsd":   true,
+	"hurd":      true,
+	"illumos":   true,
+	"ios":       true,
+	"linux":     true,
+	"netbsd":    true,
+	"openbsd":   true,
+	"solaris":   true,
+}
+
+var knownArch = map[string]bool{
+	"386":         true,
+	"amd64":       true,
+	"amd64p32":    true,
+	"arm":         true,
+	"armbe":       true,
+	"arm64":       true,
+	"arm64be":     true,
+	"loong64":     true,
+	"mips":        true,
+	"mipsle":      true,
+	"mips64":      true,
+	"mips64le":    true,
+	"mips64p32":   true,
+	"mips64p32le": true,
+	"ppc":         true,
+	"ppc64":       true,
+	"ppc64le":     true,
+	"riscv":       true,
+	"riscv64":     true,
+	"s390":        true,
+	"s390x":       true,
+	"sparc":       true,
+	"sparc64":     true,
+	"wasm":        true,
+}
+
+// FilenameTags returns the GOOS/GOARCH tags implied by the file name,
+// like "linux" and "amd64" for the "foo_linux_amd64_test.go".
+func FilenameTags(filename string) []string {
+	name := filename
+	if i := strings.LastIndexByte(name, '/'); i != -1 {
+		name = name[i+1:]
+	}
+	name = strings.TrimSuffix(name, ".go")
+	name = strings.TrimSuffix(name, "_test")
+	i := strings.IndexByte(name, '_')
+	if i == -1 {
+		return nil
+	}
+	parts := strings.Split(name[i:], "_")
+	n := len(parts)
+	if n >= 2 && knownOS[parts[n-2]] && knownArch[parts[n-1]] {
+		return []string{parts[n-2], parts[n-1]}
+	}
+	if knownOS[parts[n-1]] || knownArch[parts[n-1]] {
+		return []string{parts[n-1]}
+	}
+	return nil
+}
+
+// Target describes a build configuration.
+type Target struct {
+	GOOS   string
+	GOARCH string
+}
+
+// HasTag reports whether the tag is satisfied by the target.
+//
+// The release tags (go1.N) and the "gc" and "cgo" tags are always
+// satisfied, as they would be for the default native toolchain.
+// All other custom tags are unset.
+func (t Target) HasTag(tag string) bool {
+	switch tag {
+	case t.GOOS, t.GOARCH, "gc", "cgo":
+		return true
+	case "unix":
+		return unixOS[t.GOOS]
+	case "linux":
+		return t.GOOS == "android"
+	case "solaris":
+		return t.GOOS == "illumos"
+	case "darwin":
+		return t.GOOS == "ios"
+	}
+	return strings.HasPrefix(tag, "go1.")
+}
+
+// Builds reports whether a file with the given constraint expression
+// and filename tags is a part of the target build.
+// An empty expr means "no constraints".
+func Builds(t Target, expr string, filenameTags []string) bool {
+	for _, tag := range filenameTags {
+		if !t.HasTag(tag) {
+			return false
+		}
+	}
+	if expr == "" {
+		return true
+	}
+	x, err := constraint.Parse("//go:build " + expr)
+	if err != nil {
+		return false
+	}
+	return x.Eval(t.HasTag)
+}
+
+// Mentions reports whether the tag is mentioned in the constraint
+// expression or is implied by the file name.
+func Mentions(expr string, filenameTags []string, tag string) bool {
+	for _, t := range filenameTags {
+		if t == tag {
+			return true
+		}
+	}
+	if expr == "" {
+		return false
+	}
+	x, err := constraint.Parse("//go:build " + expr)
+	if err != nil {
+		return false
+	}
+	return exprMentions(x, tag)
+}
+
+func exprMentions(x constraint.Expr, tag string) bool {
+	switch x := x.(type) {
+	case *constraint.TagExpr:
+		return x.Tag == tag
+	case *constraint.NotExpr:
+		return exprMentions(x.X, tag)
+	case *constraint.AndExpr:
+		return exprMentions(x.X, tag) || exprMentions(x.Y, tag)
+	case *constraint.OrExpr:
+		return exprMentions(x.X, tag) || exprMentions(x.Y, tag)
+	default:
+		return false
+	}
+}
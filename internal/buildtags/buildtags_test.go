@@ -0,0 +1,87 @@
+package buildtags
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFilenameTags(t *testing.T) {
+	tests := []struct {
+		filename string
+		tags     string
+	}{
+		{"foo.go", ""},
+		{"linux.go", ""},
+		{"foo_linux.go", "linux"},
+		{"foo_amd64.go", "amd64"},
+		{"foo_linux_amd64.go", "linux amd64"},
+		{"foo_linux_amd64_test.go", "linux amd64"},
+		{"dir/foo_windows_test.go", "windows"},
+		{"foo_amd64_linux.go", "linux"},
+		{"foo_bar.go", ""},
+	}
+
+	for _, test := range tests {
+		have := strings.Join(FilenameTags(test.filename), " ")
+		if have != test.tags {
+			t.Errorf("FilenameTags(%q):\nhave: %s\nwant: %s", test.filename, have, test.tags)
+		}
+	}
+}
+
+func TestBuilds(t *testing.T) {
+	linux := Target{GOOS: "linux", GOARCH: "amd64"}
+	windows := Target{GOOS: "windows", GOARCH: "amd64"}
+	android := Target{GOOS: "android", GOARCH: "arm64"}
+
+	tests := []struct {
+		target Target
+		expr   string
+		tags   []string
+		want   bool
+	}{
+		{linux, "", nil, true},
+		{linux, "linux", nil, true},
+		{windows, "linux", nil, false},
+		{linux, "unix && !appengine", nil, true},
+		{windows, "unix", nil, false},
+		{linux, "go1.21", nil, true},
+		{linux, "!cgo", nil, false},
+		{linux, "", []string{"linux", "amd64"}, true},
+		{linux, "", []string{"arm64"}, false},
+		{android, "", []string{"linux"}, true},
+		{android, "linux && !android", nil, false},
+		{linux, "(", nil, false},
+	}
+
+	for _, test := range tests {
+		have := Builds(test.target, test.expr, test.tags)
+		if have != test.want {
+			t.Errorf("Builds(%v, %q, %v): have %v, want %v", test.target, test.expr, test.tags, have, test.want)
+		}
+	}
+}
+
+func TestMentions(t *testing.T) {
+	tests := []struct {
+		expr string
+		tags []string
+		tag  string
+		want bool
+	}{
+		{"", nil, "cgo", false},
+		{"cgo", nil, "cgo", true},
+		{"linux && !cgo", nil, "cgo", true},
+		{"linux || darwin", nil, "cgo", false},
+		{"linux || cgo", nil, "cgo", true},
+		{"!linux && cgo", nil, "cgo", true},
+		{"", []string{"windows"}, "windows", true},
+	}
+
+	for _, test := range tests {
+		have := Mentions(test.expr, test.tags, test.tag)
+		if have != test.want {
+			t.Errorf("Mentions(%q, %v, %q): have %v, want %v", test.expr, test.tags, test.tag, have, test.want)
+		}
+	}
+}
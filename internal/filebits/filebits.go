@@ -7,6 +7,34 @@ const (
 	ImportsC
 	ImportsUnsafe
 	ImportsReflect
+	UsesGenericDecls
+
+	// UsesGenericInst, UsesMethodValues and UsesRangeFunc are detected
+	// without the type information, so they are only set for the
+	// uses that can be proven. See also the MayUse* bits.
+	UsesGenericInst
+
+	UsesGoroutines
+	UsesSelect
+	UsesDefer
+	UsesLabeledBranch
+	UsesGoto
+	UsesMethodValues
+	UsesRangeFunc
+	UsesTypeSwitch
+	UsesEmbedding
+
+	// IsDuplicate is set for all but the first file with the same
+	// minified contents across the whole corpus.
+	// The web app recomputes it for the repositories being scanned.
+	IsDuplicate
+
+	// MayUseGenericInst, MayUseMethodValues and MayUseRangeFunc are
+	// the conservative counterparts of the Uses* bits: they are only
+	// cleared if the file certainly doesn't use the feature.
+	MayUseGenericInst
+	MayUseMethodValues
+	MayUseRangeFunc
 )
 
 func Check(bitSet, mask int) bool {
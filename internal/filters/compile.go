@@ -5,8 +5,12 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/quasilyte/gocorpus/internal/goversion"
+	"github.com/quasilyte/gocorpus/internal/imports"
 )
 
 func CompileExpr(s string) (*Expr, Info, error) {
@@ -86,6 +90,16 @@ func (cl *compiler) CompileExpr(root ast.Expr) (*Expr, error) {
 
 func (cl *compiler) compileCallExpr(root *ast.CallExpr) (*Expr, error) {
 	if selector, ok := root.Fun.(*ast.SelectorExpr); ok {
+		// $tag.StructTag("key").HasOption("opt")
+		if call, ok := selector.X.(*ast.CallExpr); ok {
+			varname, key, err := cl.unpackStructTagCall(call)
+			if err != nil {
+				return nil, err
+			}
+			if varname != "" {
+				return cl.compileStructTagMethodCallExpr(root, varname, key, selector.Sel)
+			}
+		}
 		return cl.compileMethodCallExpr(root, selector)
 	}
 	return nil, fmt.Errorf("compile call expr: unsupported %T function", root.Fun)
@@ -93,15 +107,36 @@ func (cl *compiler) compileCallExpr(root *ast.CallExpr) (*Expr, error) {
 
 func (cl *compiler) compileMethodCallExpr(root *ast.CallExpr, selector *ast.SelectorExpr) (*Expr, error) {
 	var object string
-	ident, ok := selector.X.(*ast.Ident)
-	if ok {
-		object = ident.Name
+	switch x := selector.X.(type) {
+	case *ast.Ident:
+		object = x.Name
+	case *ast.SelectorExpr:
+		if ident, ok := x.X.(*ast.Ident); ok {
+			object = ident.Name + "." + x.Sel.Name
+		}
 	}
 
 	switch object {
 	case "file":
 		return cl.compileFileMethodCallExpr(root, selector.Sel)
+	case "match":
+		return cl.compileMatchMethodCallExpr(root, selector.Sel)
+	case "match.Func":
+		return cl.compileMatchFuncMethodCallExpr(root, selector.Sel)
+	case "pkg":
+		return cl.compilePkgMethodCallExpr(root, selector.Sel)
+	case "decl":
+		return cl.compileDeclMethodCallExpr(root, selector.Sel)
+	case "repo":
+		return cl.compileRepoMethodCallExpr(root, selector.Sel)
 	default:
+		// $tag.StructTag.HasOption("opt")
+		if prefix := strings.TrimSuffix(object, ".StructTag"); prefix != object && isPatternVar(prefix) {
+			return cl.compileStructTagMethodCallExpr(root, patternVarName(prefix), "", selector.Sel)
+		}
+		if prop := strings.TrimPrefix(object, "decl."); prop != object {
+			return cl.compileDeclPropMethodCallExpr(root, prop, selector.Sel)
+		}
 		if isPatternVar(object) {
 			return cl.compilePatternVarMethodCallExpr(root, patternVarName(object), selector.Sel)
 		}
@@ -125,11 +160,148 @@ func (cl *compiler) compilePatternVarMethodCallExpr(root *ast.CallExpr, varname
 		return &Expr{Op: OpVarIsFloatLit, Str: varname}, nil
 	case "IsComplexLit":
 		return &Expr{Op: OpVarIsComplexLit, Str: varname}, nil
+	case "Calls":
+		symbol, err := cl.stringArg(root, method)
+		if err != nil {
+			return nil, err
+		}
+		if _, _, ok := imports.SplitSymbol(symbol); !ok {
+			return nil, fmt.Errorf("%s: %q is not a pkgpath.Name symbol", method.Name, symbol)
+		}
+		return &Expr{Op: OpVarCalls, Str: varname, Value: symbol}, nil
+	case "RefersToPackage":
+		pkgPath, err := cl.stringArg(root, method)
+		if err != nil {
+			return nil, err
+		}
+		return &Expr{Op: OpVarRefersToPackage, Str: varname, Value: pkgPath}, nil
+	case "StructTag":
+		key, err := cl.structTagKeyArg(root, method)
+		if err != nil {
+			return nil, err
+		}
+		return &Expr{Op: OpVarStructTagHas, Str: varname, Value: key}, nil
 	default:
 		return nil, fmt.Errorf("compile %s method call: unsupported %s method", varname, method.Name)
 	}
 }
 
+func (cl *compiler) compileStructTagMethodCallExpr(root *ast.CallExpr, varname, key string, method *ast.Ident) (*Expr, error) {
+	switch method.Name {
+	case "HasOption":
+		option, err := cl.stringArg(root, method)
+		if err != nil {
+			return nil, err
+		}
+		return &Expr{Op: OpVarStructTagHasOption, Str: varname, Value: key + ":" + strconv.Quote(option)}, nil
+	default:
+		return nil, fmt.Errorf("compile %s.StructTag method call: unsupported %s method", varname, method.Name)
+	}
+}
+
+func (cl *compiler) compileMatchMethodCallExpr(root *ast.CallExpr, method *ast.Ident) (*Expr, error) {
+	switch method.Name {
+	case "HasComment":
+		pattern, err := cl.stringArg(root, method)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("%s: %v", method.Name, err)
+		}
+		cl.info.NeedComments = true
+		return &Expr{Op: OpMatchHasComment, Value: pattern}, nil
+	case "IsSuppressed":
+		var linter string
+		if len(root.Args) != 0 {
+			s, err := cl.stringArg(root, method)
+			if err != nil {
+				return nil, err
+			}
+			linter = s
+		}
+		cl.info.NeedComments = true
+		return &Expr{Op: OpMatchIsSuppressed, Value: linter}, nil
+	default:
+		return nil, fmt.Errorf("compile match method call: unsupported %s method", method.Name)
+	}
+}
+
+func (cl *compiler) compileMatchFuncMethodCallExpr(root *ast.CallExpr, method *ast.Ident) (*Expr, error) {
+	switch method.Name {
+	case "HasDoc":
+		cl.info.NeedComments = true
+		return &Expr{Op: OpMatchFuncHasDoc}, nil
+	default:
+		return nil, fmt.Errorf("compile match.Func method call: unsupported %s method", method.Name)
+	}
+}
+
+func (cl *compiler) compilePkgMethodCallExpr(root *ast.CallExpr, method *ast.Ident) (*Expr, error) {
+	switch method.Name {
+	case "HasMethod":
+		if len(root.Args) != 2 {
+			return nil, fmt.Errorf("%s: expected 2 arguments, found %d", method.Name, len(root.Args))
+		}
+		varname, ok := cl.toPatternVar(root.Args[0])
+		if !ok {
+			return nil, fmt.Errorf("%s: expected a pattern var argument", method.Name)
+		}
+		name, ok := cl.toString(root.Args[1])
+		if !ok {
+			return nil, fmt.Errorf("%s: expected a string literal argument", method.Name)
+		}
+		cl.info.NeedPackage = true
+		return &Expr{Op: OpPkgHasMethod, Str: varname, Value: name}, nil
+	case "References":
+		if len(root.Args) != 1 {
+			return nil, fmt.Errorf("%s: expected 1 argument, found %d", method.Name, len(root.Args))
+		}
+		varname, ok := cl.toPatternVar(root.Args[0])
+		if !ok {
+			return nil, fmt.Errorf("%s: expected a pattern var argument", method.Name)
+		}
+		cl.info.NeedPackage = true
+		return &Expr{Op: OpPkgReferences, Str: varname}, nil
+	default:
+		return nil, fmt.Errorf("compile pkg method call: unsupported %s method", method.Name)
+	}
+}
+
+func (cl *compiler) compileDeclMethodCallExpr(root *ast.CallExpr, method *ast.Ident) (*Expr, error) {
+	if kind, ok := declKindMethods[method.Name]; ok {
+		cl.info.NeedDecl = true
+		return &Expr{Op: OpDeclKindIs, Value: kind}, nil
+	}
+	switch method.Name {
+	case "IsExported":
+		cl.info.NeedDecl = true
+		return &Expr{Op: OpDeclIsExported}, nil
+	default:
+		return nil, fmt.Errorf("compile decl method call: unsupported %s method", method.Name)
+	}
+}
+
+func (cl *compiler) compileDeclPropMethodCallExpr(root *ast.CallExpr, prop string, method *ast.Ident) (*Expr, error) {
+	if !isDeclProp(prop) {
+		return nil, fmt.Errorf("compile decl.%s method call: unsupported decl property", prop)
+	}
+	switch method.Name {
+	case "Matches":
+		pattern, err := cl.stringArg(root, method)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("%s: %v", method.Name, err)
+		}
+		cl.info.NeedDecl = true
+		return &Expr{Op: OpDeclPropMatches, Str: prop, Value: pattern}, nil
+	default:
+		return nil, fmt.Errorf("compile decl.%s method call: unsupported %s method", prop, method.Name)
+	}
+}
+
 func (cl *compiler) compileFileMethodCallExpr(root *ast.CallExpr, method *ast.Ident) (*Expr, error) {
 	if !cl.isTopLevel {
 		return nil, fmt.Errorf("file filters can't be a part of || expression")
@@ -153,11 +325,90 @@ func (cl *compiler) compileFileMethodCallExpr(root *ast.CallExpr, method *ast.Id
 		}
 		cl.info.MainFileCond.SetValue(!cl.isNegated)
 		return &Expr{Op: OpNop}, nil
+	case "IsDuplicate":
+		if !cl.info.DuplicateFileCond.IsUnset() {
+			return nil, fmt.Errorf("duplicated file.IsDuplicate cond")
+		}
+		cl.info.DuplicateFileCond.SetValue(!cl.isNegated)
+		return &Expr{Op: OpNop}, nil
+	case "HasDirective":
+		name, err := cl.stringArg(root, method)
+		if err != nil {
+			return nil, err
+		}
+		name = strings.TrimPrefix(name, "go:")
+		return cl.addNamedCond("file", &cl.info.DirectiveConds, method, name)
+	case "HasBuildTag":
+		tag, err := cl.stringArg(root, method)
+		if err != nil {
+			return nil, err
+		}
+		return cl.addNamedCond("file", &cl.info.BuildTagConds, method, tag)
+	case "BuildsFor":
+		if len(root.Args) != 2 {
+			return nil, fmt.Errorf("%s: expected 2 arguments, found %d", method.Name, len(root.Args))
+		}
+		goos, ok1 := cl.toString(root.Args[0])
+		goarch, ok2 := cl.toString(root.Args[1])
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("%s: expected string literal arguments", method.Name)
+		}
+		return cl.addNamedCond("file", &cl.info.BuildsForConds, method, goos+"/"+goarch)
+	case "IsConstrained":
+		if !cl.info.ConstrainedFileCond.IsUnset() {
+			return nil, fmt.Errorf("duplicated file.IsConstrained cond")
+		}
+		cl.info.ConstrainedFileCond.SetValue(!cl.isNegated)
+		return &Expr{Op: OpNop}, nil
 	default:
+		for _, m := range fileFlagMethods {
+			if m.name != method.Name {
+				continue
+			}
+			if cl.info.FileFlagsSet&fileFlagSetMask(m.flag) != 0 || cl.info.FileFlagsUnset&m.flag != 0 {
+				return nil, fmt.Errorf("duplicated file.%s cond", method.Name)
+			}
+			if cl.isNegated {
+				cl.info.FileFlagsUnset |= m.flag
+			} else {
+				cl.info.FileFlagsSet |= fileFlagSetMask(m.flag)
+			}
+			return &Expr{Op: OpNop}, nil
+		}
 		return nil, fmt.Errorf("compile file method call: unsupported %s method", method.Name)
 	}
 }
 
+func (cl *compiler) compileRepoMethodCallExpr(root *ast.CallExpr, method *ast.Ident) (*Expr, error) {
+	// Like the file filters, the repo filters are applied
+	// before the file is parsed.
+	if !cl.isTopLevel {
+		return nil, fmt.Errorf("repo filters can't be a part of || expression")
+	}
+	switch method.Name {
+	case "DependsOn":
+		pattern, err := cl.stringArg(root, method)
+		if err != nil {
+			return nil, err
+		}
+		return cl.addNamedCond("repo", &cl.info.RepoDependsOnConds, method, pattern)
+	default:
+		return nil, fmt.Errorf("compile repo method call: unsupported %s method", method.Name)
+	}
+}
+
+func (cl *compiler) addNamedCond(object string, conds *[]NamedCond, method *ast.Ident, name string) (*Expr, error) {
+	for _, c := range *conds {
+		if c.Name == name {
+			return nil, fmt.Errorf("duplicated %s.%s(%q) cond", object, method.Name, name)
+		}
+	}
+	c := NamedCond{Name: name}
+	c.Cond.SetValue(!cl.isNegated)
+	*conds = append(*conds, c)
+	return &Expr{Op: OpNop}, nil
+}
+
 func (cl *compiler) compileUnaryExpr(root *ast.UnaryExpr) (*Expr, error) {
 	switch root.Op {
 	case token.NOT:
@@ -220,6 +471,40 @@ func (cl *compiler) invertOp(op token.Token) token.Token {
 func (cl *compiler) compileBinaryExprXY(op token.Token, x, y ast.Expr) (*Expr, error) {
 	fileProp := cl.unpackFileOperand(x)
 
+	if call, ok := x.(*ast.CallExpr); ok && (op == token.EQL || op == token.NEQ) {
+		varname, key, err := cl.unpackStructTagCall(call)
+		if err != nil {
+			return nil, err
+		}
+		if varname != "" {
+			value, ok := cl.toString(y)
+			if !ok {
+				return nil, fmt.Errorf("StructTag: expected a string literal operand")
+			}
+			e := &Expr{Op: OpVarStructTagEq, Str: varname, Value: key + ":" + strconv.Quote(value)}
+			if op == token.NEQ {
+				e = &Expr{Op: OpNot, Args: []*Expr{e}}
+			}
+			return e, nil
+		}
+	}
+
+	if declProp := cl.unpackDeclOperand(x); declProp != "" && (op == token.EQL || op == token.NEQ) {
+		if !isDeclProp(declProp) {
+			return nil, fmt.Errorf("decl.%s: unsupported decl property", declProp)
+		}
+		value, ok := cl.toString(y)
+		if !ok {
+			return nil, fmt.Errorf("decl.%s: expected a string literal operand", declProp)
+		}
+		cl.info.NeedDecl = true
+		e := &Expr{Op: OpDeclPropEq, Str: declProp, Value: value}
+		if op == token.NEQ {
+			e = &Expr{Op: OpNot, Args: []*Expr{e}}
+		}
+		return e, nil
+	}
+
 	switch op {
 	case token.LEQ, token.GEQ, token.LSS, token.GTR, token.EQL, token.NEQ:
 		if fileProp != "" {
@@ -229,10 +514,46 @@ func (cl *compiler) compileBinaryExprXY(op token.Token, x, y ast.Expr) (*Expr, e
 			if cl.isNegated {
 				op = cl.invertOp(op)
 			}
-			rhsValue, ok := cl.toInt(y)
-			if op != token.ILLEGAL && ok {
-				cl.info.FileMaxDepthOp = op
-				cl.info.FileMaxDepth = int(rhsValue)
+			switch fileProp {
+			case "MaxDepth":
+				rhsValue, ok := cl.toInt(y)
+				if op != token.ILLEGAL && ok {
+					cl.info.FileMaxDepthOp = op
+					cl.info.FileMaxDepth = int(rhsValue)
+					return &Expr{Op: OpNop}, nil
+				}
+			case "GoVersion":
+				rhsValue, ok := cl.toString(y)
+				if !ok {
+					break
+				}
+				version := goversion.Lang(rhsValue)
+				if version == "" {
+					return nil, fmt.Errorf("file.GoVersion: %q is not a valid Go version", rhsValue)
+				}
+				if op != token.ILLEGAL {
+					cl.info.FileGoVersionOp = op
+					cl.info.FileGoVersion = version
+					return &Expr{Op: OpNop}, nil
+				}
+			case "Module", "PkgPath", "PkgName":
+				pattern, ok := cl.toString(y)
+				if !ok {
+					break
+				}
+				if op != token.EQL && op != token.NEQ {
+					return nil, fmt.Errorf("file.%s: only == and != are supported", fileProp)
+				}
+				conds := &cl.info.FileModuleConds
+				switch fileProp {
+				case "PkgPath":
+					conds = &cl.info.FilePkgPathConds
+				case "PkgName":
+					conds = &cl.info.FilePkgNameConds
+				}
+				c := NamedCond{Name: pattern}
+				c.Cond.SetValue(op == token.EQL)
+				*conds = append(*conds, c)
 				return &Expr{Op: OpNop}, nil
 			}
 		}
@@ -286,6 +607,45 @@ func (cl *compiler) toInt(e ast.Expr) (int64, bool) {
 	}
 }
 
+func (cl *compiler) toString(e ast.Expr) (string, bool) {
+	switch e := e.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return "", false
+		}
+		v, err := strconv.Unquote(e.Value)
+		if err != nil {
+			return "", false
+		}
+		return v, true
+
+	case *ast.ParenExpr:
+		return cl.toString(e.X)
+
+	default:
+		return "", false
+	}
+}
+
+func (cl *compiler) toPatternVar(e ast.Expr) (string, bool) {
+	ident, ok := e.(*ast.Ident)
+	if !ok || !isPatternVar(ident.Name) {
+		return "", false
+	}
+	return patternVarName(ident.Name), true
+}
+
+func (cl *compiler) stringArg(call *ast.CallExpr, method *ast.Ident) (string, error) {
+	if len(call.Args) != 1 {
+		return "", fmt.Errorf("%s: expected 1 argument, found %d", method.Name, len(call.Args))
+	}
+	s, ok := cl.toString(call.Args[0])
+	if !ok {
+		return "", fmt.Errorf("%s: expected a string literal argument", method.Name)
+	}
+	return s, nil
+}
+
 func (cl *compiler) unpackFileOperand(e ast.Expr) string {
 	call, ok := e.(*ast.CallExpr)
 	if !ok {
@@ -304,3 +664,50 @@ func (cl *compiler) unpackFileOperand(e ast.Expr) string {
 	}
 	return selector.Sel.Name
 }
+
+// unpackStructTagCall unpacks the $tag.StructTag("key") expression.
+// It returns an empty varname if e has some other form.
+func (cl *compiler) unpackStructTagCall(e *ast.CallExpr) (varname, key string, err error) {
+	selector, ok := e.Fun.(*ast.SelectorExpr)
+	if !ok || selector.Sel.Name != "StructTag" {
+		return "", "", nil
+	}
+	varname, ok = cl.toPatternVar(selector.X)
+	if !ok {
+		return "", "", nil
+	}
+	key, err = cl.structTagKeyArg(e, selector.Sel)
+	return varname, key, err
+}
+
+func (cl *compiler) structTagKeyArg(call *ast.CallExpr, method *ast.Ident) (string, error) {
+	key, err := cl.stringArg(call, method)
+	if err != nil {
+		return "", err
+	}
+	if key == "" || strings.ContainsAny(key, " :\"") {
+		return "", fmt.Errorf("%s: %q is not a valid struct tag key", method.Name, key)
+	}
+	return key, nil
+}
+
+func (cl *compiler) unpackDeclOperand(e ast.Expr) string {
+	selector, ok := e.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	object, ok := selector.X.(*ast.Ident)
+	if !ok || object.Name != "decl" {
+		return ""
+	}
+	return selector.Sel.Name
+}
+
+func isDeclProp(name string) bool {
+	for _, prop := range declProps {
+		if prop == name {
+			return true
+		}
+	}
+	return false
+}
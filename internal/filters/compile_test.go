@@ -3,6 +3,8 @@ package filters
 import (
 	"fmt"
 	"testing"
+
+	"github.com/quasilyte/gocorpus/internal/filebits"
 )
 
 func TestCompile(t *testing.T) {
@@ -49,6 +51,75 @@ func TestCompile(t *testing.T) {
 			info:  `MainFileCond=false`,
 		},
 
+		{
+			input: `file.IsDuplicate()`,
+			expr:  `Nop`,
+			info:  `DuplicateFileCond=true`,
+		},
+		{
+			input: `!file.IsDuplicate() && file.IsMain()`,
+			expr:  `Nop`,
+			info:  `MainFileCond=true DuplicateFileCond=false`,
+		},
+
+		{
+			input: `file.UsesGoroutines()`,
+			expr:  `Nop`,
+			info:  `UsesGoroutines=true`,
+		},
+		{
+			input: `!file.UsesGenericDecls() && file.UsesGenericInst() && !file.IsTest()`,
+			expr:  `Nop`,
+			info:  `TestFileCond=false UsesGenericDecls=false UsesGenericInst=true`,
+		},
+		{
+			input: `file.UsesRangeFunc() && $x.IsPure()`,
+			expr:  `(VarIsPure "x")`,
+			info:  `UsesRangeFunc=true`,
+		},
+		{
+			input: `!file.UsesRangeFunc() && !file.UsesMethodValues()`,
+			expr:  `Nop`,
+			info:  `UsesMethodValues=false UsesRangeFunc=false`,
+		},
+
+		{
+			input: `file.HasDirective("linkname")`,
+			expr:  `Nop`,
+			info:  `HasDirective(linkname)=true`,
+		},
+		{
+			input: `file.HasDirective("go:embed") && !file.HasDirective("generate")`,
+			expr:  `Nop`,
+			info:  `HasDirective(embed)=true HasDirective(generate)=false`,
+		},
+
+		{
+			input: `file.BuildsFor("linux", "amd64")`,
+			expr:  `Nop`,
+			info:  `BuildsFor(linux/amd64)=true`,
+		},
+		{
+			input: `!file.BuildsFor("windows", "amd64") && file.HasBuildTag("cgo")`,
+			expr:  `Nop`,
+			info:  `BuildsFor(windows/amd64)=false HasBuildTag(cgo)=true`,
+		},
+		{
+			input: `!file.IsConstrained()`,
+			expr:  `Nop`,
+			info:  `ConstrainedFileCond=false`,
+		},
+		{
+			input: `repo.DependsOn("github.com/quasilyte/...") && !repo.DependsOn("golang.org/x/tools")`,
+			expr:  `Nop`,
+			info:  `DependsOn(github.com/quasilyte/...)=true DependsOn(golang.org/x/tools)=false`,
+		},
+		{
+			input: `repo.DependsOn("golang.org/x/sync") && $x.IsPure()`,
+			expr:  `(VarIsPure "x")`,
+			info:  `DependsOn(golang.org/x/sync)=true`,
+		},
+
 		{
 			input: `$x.IsPure()`,
 			expr:  `(VarIsPure "x")`,
@@ -79,6 +150,71 @@ func TestCompile(t *testing.T) {
 			expr:  `(VarIsComplexLit "x")`,
 		},
 
+		{
+			input: `$call.Calls("net/http.Get")`,
+			expr:  `(VarCalls "call" "net/http.Get")`,
+		},
+		{
+			input: `!$f.Calls("gopkg.in/yaml.v3.Marshal")`,
+			expr:  `(Not (VarCalls "f" "gopkg.in/yaml.v3.Marshal"))`,
+		},
+		{
+			input: `$x.RefersToPackage("net/http") && !file.IsTest()`,
+			expr:  `(VarRefersToPackage "x" "net/http")`,
+			info:  `TestFileCond=false`,
+		},
+
+		{
+			input: `match.HasComment("TODO")`,
+			expr:  `(MatchHasComment "TODO")`,
+			info:  `NeedComments`,
+		},
+		{
+			input: `match.Func.HasDoc() && !file.IsTest()`,
+			expr:  `MatchFuncHasDoc`,
+			info:  `TestFileCond=false NeedComments`,
+		},
+		{
+			input: `!match.IsSuppressed()`,
+			expr:  `(Not MatchIsSuppressed)`,
+			info:  `NeedComments`,
+		},
+		{
+			input: `pkg.HasMethod($T, "String") && !pkg.HasMethod($T, "Error")`,
+			expr:  `(And (PkgHasMethod "T" "String") (Not (PkgHasMethod "T" "Error")))`,
+			info:  `NeedPackage`,
+		},
+		{
+			input: `!pkg.References($name) && !file.IsTest()`,
+			expr:  `(Not (PkgReferences "name"))`,
+			info:  `TestFileCond=false NeedPackage`,
+		},
+		{
+			input: `$tag.StructTag("json") && $tag.StructTag("json") != "-"`,
+			expr:  `(And (VarStructTagHas "tag" "json") (Not (VarStructTagEq "tag" "json:\"-\"")))`,
+			info:  ``,
+		},
+		{
+			input: `$tag.StructTag("yaml").HasOption("omitempty") || $tag.StructTag.HasOption("inline")`,
+			expr:  `(Or (VarStructTagHasOption "tag" "yaml:\"omitempty\"") (VarStructTagHasOption "tag" ":\"inline\""))`,
+			info:  ``,
+		},
+		{
+			input: `decl.IsMethod() && decl.Receiver == "*Client" && decl.Name.Matches("^With")`,
+			expr:  `(And (And (DeclKindIs "method") (DeclPropEq "Receiver" "*Client")) (DeclPropMatches "Name" "^With"))`,
+			info:  `NeedDecl`,
+		},
+		{
+			input: `decl.IsType() && decl.TypeKind != "alias" && !decl.IsExported() && file.IsTest()`,
+			expr:  `(And (And (DeclKindIs "type") (Not (DeclPropEq "TypeKind" "alias"))) (Not DeclIsExported))`,
+			info:  `TestFileCond=true NeedDecl`,
+		},
+		{
+			input: `match.IsSuppressed("gocritic") || $x.IsConst()`,
+			expr:  `(Or (MatchIsSuppressed "gocritic") (VarIsConst "x"))`,
+			info:  `NeedComments`,
+		},
+
 		{
 			input: `!file.IsAutogen() && (!$x.IsPure() || !$y.IsPure())`,
 			expr:  `(Or (Not (VarIsPure "x")) (Not (VarIsPure "y")))`,
@@ -132,6 +268,43 @@ func TestCompile(t *testing.T) {
 			expr:  `Nop`,
 			info:  `FileMaxDepth==10`,
 		},
+
+		{
+			input: `file.GoVersion() >= "1.21"`,
+			expr:  `Nop`,
+			info:  `FileGoVersion>=1.21`,
+		},
+		{
+			input: `"go1.18" > file.GoVersion() && file.MaxDepth() < 50`,
+			expr:  `Nop`,
+			info:  `FileMaxDepth<50 FileGoVersion<1.18`,
+		},
+		{
+			input: `!(file.GoVersion() == "1.20.3")`,
+			expr:  `Nop`,
+			info:  `FileGoVersion!=1.20`,
+		},
+
+		{
+			input: `file.Module() == "golang.org/x/tools/gopls"`,
+			expr:  `Nop`,
+			info:  `Module(golang.org/x/tools/gopls)=true`,
+		},
+		{
+			input: `file.PkgPath() == "net/..." && !(file.PkgPath() == "net/http/...")`,
+			expr:  `Nop`,
+			info:  `PkgPath(net/...)=true PkgPath(net/http/...)=false`,
+		},
+		{
+			input: `"k8s.io/kubernetes" != file.Module() && file.GoVersion() >= "1.21"`,
+			expr:  `Nop`,
+			info:  `FileGoVersion>=1.21 Module(k8s.io/kubernetes)=false`,
+		},
+		{
+			input: `file.PkgName() != "main" && file.PkgPath() == "k8s.io/kubernetes/pkg/..."`,
+			expr:  `Nop`,
+			info:  `PkgPath(k8s.io/kubernetes/pkg/...)=true PkgName(main)=false`,
+		},
 	}
 
 	for i := range tests {
@@ -151,3 +324,32 @@ func TestCompile(t *testing.T) {
 		})
 	}
 }
+
+func TestCompileFileFlags(t *testing.T) {
+	tests := []struct {
+		input string
+		set   int
+		unset int
+	}{
+		{`file.UsesGoroutines()`, filebits.UsesGoroutines, 0},
+		{`!file.UsesGoroutines()`, 0, filebits.UsesGoroutines},
+
+		// The heuristic features require the conservative bit to be set
+		// and the proven usage bit to be unset.
+		{`file.UsesGenericInst()`, filebits.MayUseGenericInst, 0},
+		{`!file.UsesGenericInst()`, 0, filebits.UsesGenericInst},
+		{`file.UsesMethodValues() && !file.UsesRangeFunc()`, filebits.MayUseMethodValues, filebits.UsesRangeFunc},
+		{`!file.UsesMethodValues() && file.UsesRangeFunc() && file.UsesDefer()`, filebits.MayUseRangeFunc | filebits.UsesDefer, filebits.UsesMethodValues},
+	}
+
+	for _, test := range tests {
+		_, info, err := CompileExpr(test.input)
+		if err != nil {
+			t.Fatalf("compile %q: %v", test.input, err)
+		}
+		if info.FileFlagsSet != test.set || info.FileFlagsUnset != test.unset {
+			t.Errorf("%s:\nhave set=%b unset=%b\nwant set=%b unset=%b",
+				test.input, info.FileFlagsSet, info.FileFlagsUnset, test.set, test.unset)
+		}
+	}
+}
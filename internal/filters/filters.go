@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"go/token"
 	"strings"
+
+	"github.com/quasilyte/gocorpus/internal/filebits"
 )
 
 type Info struct {
@@ -11,8 +13,53 @@ type Info struct {
 	AutogenFileCond Bool3
 	MainFileCond    Bool3
 
+	// DuplicateFileCond is the file.IsDuplicate() condition.
+	// Note that the duplicates are excluded if it's unset.
+	DuplicateFileCond Bool3
+
 	FileMaxDepth   int
 	FileMaxDepthOp token.Token
+
+	FileGoVersion   string
+	FileGoVersionOp token.Token
+
+	// FileModuleConds and FilePkgPathConds names are import path
+	// patterns (see imports.MatchPattern).
+	FileModuleConds  []NamedCond
+	FilePkgPathConds []NamedCond
+
+	FilePkgNameConds []NamedCond
+
+	// FileFlagsSet and FileFlagsUnset are the filebits masks
+	// for the file.Uses*() conditions.
+	// For the heuristically detected features, FileFlagsSet
+	// has the MayUse* bit and FileFlagsUnset has the Uses* bit,
+	// so a file is only skipped if the feature usage is known.
+	FileFlagsSet   int
+	FileFlagsUnset int
+
+	DirectiveConds []NamedCond
+
+	// BuildsForConds names have a "GOOS/GOARCH" form.
+	BuildsForConds      []NamedCond
+	BuildTagConds       []NamedCond
+	ConstrainedFileCond Bool3
+
+	// RepoDependsOnConds names are module path patterns (see imports.MatchPattern)
+	// that are matched against the go.mod requirements of the file repository.
+	RepoDependsOnConds []NamedCond
+
+	// NeedComments is set when some filter inspects the source comments,
+	// so the target file should be parsed with parser.ParseComments.
+	NeedComments bool
+
+	// NeedPackage is set when some filter uses the pkg object,
+	// so the query can only be executed in the package mode.
+	NeedPackage bool
+
+	// NeedDecl is set when some filter uses the decl object,
+	// so the query can only be executed in the decl search mode.
+	NeedDecl bool
 }
 
 func (i Info) String() string {
@@ -26,16 +73,124 @@ func (i Info) String() string {
 	if !i.MainFileCond.IsUnset() {
 		parts = append(parts, "MainFileCond="+i.MainFileCond.String())
 	}
+	if !i.DuplicateFileCond.IsUnset() {
+		parts = append(parts, "DuplicateFileCond="+i.DuplicateFileCond.String())
+	}
 	if i.FileMaxDepthOp != token.ILLEGAL {
 		parts = append(parts, fmt.Sprintf("FileMaxDepth%s%d", i.FileMaxDepthOp, i.FileMaxDepth))
 	}
+	if i.FileGoVersionOp != token.ILLEGAL {
+		parts = append(parts, fmt.Sprintf("FileGoVersion%s%s", i.FileGoVersionOp, i.FileGoVersion))
+	}
+	for _, c := range i.FileModuleConds {
+		parts = append(parts, fmt.Sprintf("Module(%s)=%s", c.Name, c.Cond))
+	}
+	for _, c := range i.FilePkgPathConds {
+		parts = append(parts, fmt.Sprintf("PkgPath(%s)=%s", c.Name, c.Cond))
+	}
+	for _, c := range i.FilePkgNameConds {
+		parts = append(parts, fmt.Sprintf("PkgName(%s)=%s", c.Name, c.Cond))
+	}
+	for _, m := range fileFlagMethods {
+		switch {
+		case i.FileFlagsSet&fileFlagSetMask(m.flag) != 0:
+			parts = append(parts, m.name+"=true")
+		case i.FileFlagsUnset&m.flag != 0:
+			parts = append(parts, m.name+"=false")
+		}
+	}
+	for _, c := range i.DirectiveConds {
+		parts = append(parts, fmt.Sprintf("HasDirective(%s)=%s", c.Name, c.Cond))
+	}
+	for _, c := range i.BuildsForConds {
+		parts = append(parts, fmt.Sprintf("BuildsFor(%s)=%s", c.Name, c.Cond))
+	}
+	for _, c := range i.BuildTagConds {
+		parts = append(parts, fmt.Sprintf("HasBuildTag(%s)=%s", c.Name, c.Cond))
+	}
+	if !i.ConstrainedFileCond.IsUnset() {
+		parts = append(parts, "ConstrainedFileCond="+i.ConstrainedFileCond.String())
+	}
+	for _, c := range i.RepoDependsOnConds {
+		parts = append(parts, fmt.Sprintf("DependsOn(%s)=%s", c.Name, c.Cond))
+	}
+	if i.NeedComments {
+		parts = append(parts, "NeedComments")
+	}
+	if i.NeedPackage {
+		parts = append(parts, "NeedPackage")
+	}
+	if i.NeedDecl {
+		parts = append(parts, "NeedDecl")
+	}
 	return strings.Join(parts, " ")
 }
 
+// fileFlagMethods maps the file.Method() filters to the associated file flags.
+var fileFlagMethods = []struct {
+	name string
+	flag int
+}{
+	{"UsesGenericDecls", filebits.UsesGenericDecls},
+	{"UsesGenericInst", filebits.UsesGenericInst},
+	{"UsesGoroutines", filebits.UsesGoroutines},
+	{"UsesSelect", filebits.UsesSelect},
+	{"UsesDefer", filebits.UsesDefer},
+	{"UsesLabeledBranch", filebits.UsesLabeledBranch},
+	{"UsesGoto", filebits.UsesGoto},
+	{"UsesMethodValues", filebits.UsesMethodValues},
+	{"UsesRangeFunc", filebits.UsesRangeFunc},
+	{"UsesTypeSwitch", filebits.UsesTypeSwitch},
+	{"UsesEmbedding", filebits.UsesEmbedding},
+}
+
+// mayUseFlags maps the heuristically detected file flags
+// to their conservative MayUse* counterparts.
+var mayUseFlags = map[int]int{
+	filebits.UsesGenericInst:  filebits.MayUseGenericInst,
+	filebits.UsesMethodValues: filebits.MayUseMethodValues,
+	filebits.UsesRangeFunc:    filebits.MayUseRangeFunc,
+}
+
+// fileFlagSetMask returns a flag that is required by the non-negated file.Uses*() filter.
+// The negated filter requires the flag itself to be unset.
+func fileFlagSetMask(flag int) int {
+	if mayFlag, ok := mayUseFlags[flag]; ok {
+		return mayFlag
+	}
+	return flag
+}
+
+// declKindMethods maps the decl.Method() filters to the declaration kinds.
+var declKindMethods = map[string]string{
+	"IsFunc":   "func",
+	"IsMethod": "method",
+	"IsType":   "type",
+	"IsConst":  "const",
+	"IsVar":    "var",
+}
+
+// declProps are the decl string properties that can be
+// compared with == and != or matched with Matches().
+var declProps = []string{
+	"Kind",
+	"Name",
+	"Receiver",
+	"TypeKind",
+	"Signature",
+}
+
+// NamedCond is a file condition that is parametrized by a name.
+type NamedCond struct {
+	Name string
+	Cond Bool3
+}
+
 type Expr struct {
-	Op   Operation
-	Args []*Expr
-	Str  string
+	Op    Operation
+	Args  []*Expr
+	Str   string
+	Value string
 }
 
 //go:generate stringer -type=Operation -trimprefix=Op
@@ -76,4 +231,59 @@ const (
 
 	// OpVarIsComplexLit = vars[$Str].IsComplexLit()
 	OpVarIsComplexLit
+
+	// OpVarCalls = vars[$Str].Calls($Value)
+	// $Value is a "pkgpath.Name" symbol string.
+	OpVarCalls
+
+	// OpVarRefersToPackage = vars[$Str].RefersToPackage($Value)
+	// $Value is a package import path.
+	OpVarRefersToPackage
+
+	// OpVarStructTagHas = vars[$Str].StructTag($Value)
+	// $Value is a tag key; the condition is true if the key is present.
+	OpVarStructTagHas
+
+	// OpVarStructTagEq = vars[$Str].StructTag(key) == value
+	// $Value is encoded as a key:"value" struct tag.
+	OpVarStructTagEq
+
+	// OpVarStructTagHasOption = vars[$Str].StructTag(key).HasOption(option)
+	// $Value is encoded as a key:"option" struct tag.
+	// An empty key matches the option of any key.
+	OpVarStructTagHasOption
+
+	// OpMatchHasComment = match.HasComment($Value)
+	// $Value is a regexp that is matched against the comments
+	// located on the matched node lines.
+	OpMatchHasComment
+
+	// OpMatchFuncHasDoc = match.Func.HasDoc()
+	OpMatchFuncHasDoc
+
+	// OpMatchIsSuppressed = match.IsSuppressed($Value)
+	// $Value is an optional linter name.
+	OpMatchIsSuppressed
+
+	// OpPkgHasMethod = pkg.HasMethod(vars[$Str], $Value)
+	// $Value is a method name.
+	OpPkgHasMethod
+
+	// OpPkgReferences = pkg.References(vars[$Str])
+	OpPkgReferences
+
+	// OpDeclKindIs = decl.Kind == $Value
+	// It's produced by the decl.IsFunc() and similar methods.
+	OpDeclKindIs
+
+	// OpDeclIsExported = decl.IsExported()
+	OpDeclIsExported
+
+	// OpDeclPropEq = decl.$Str == $Value
+	// $Str is a declProps element.
+	OpDeclPropEq
+
+	// OpDeclPropMatches = decl.$Str.Matches($Value)
+	// $Str is a declProps element, $Value is a regexp.
+	OpDeclPropMatches
 )
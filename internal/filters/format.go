@@ -10,6 +10,9 @@ func Sprint(e *Expr) string {
 	if e.Str != "" {
 		parts = append(parts, fmt.Sprintf("%q", e.Str))
 	}
+	if e.Value != "" {
+		parts = append(parts, fmt.Sprintf("%q", e.Value))
+	}
 	for _, arg := range e.Args {
 		parts = append(parts, Sprint(arg))
 	}
@@ -20,11 +20,25 @@ func _() {
 	_ = x[OpVarIsIntLit-9]
 	_ = x[OpVarIsFloatLit-10]
 	_ = x[OpVarIsComplexLit-11]
+	_ = x[OpVarCalls-12]
+	_ = x[OpVarRefersToPackage-13]
+	_ = x[OpVarStructTagHas-14]
+	_ = x[OpVarStructTagEq-15]
+	_ = x[OpVarStructTagHasOption-16]
+	_ = x[OpMatchHasComment-17]
+	_ = x[OpMatchFuncHasDoc-18]
+	_ = x[OpMatchIsSuppressed-19]
+	_ = x[OpPkgHasMethod-20]
+	_ = x[OpPkgReferences-21]
+	_ = x[OpDeclKindIs-22]
+	_ = x[OpDeclIsExported-23]
+	_ = x[OpDeclPropEq-24]
+	_ = x[OpDeclPropMatches-25]
 }
 
-const _Operation_name = "InvalidNopNotAndOrVarIsConstVarIsPureVarIsStringLitVarIsRuneLitVarIsIntLitVarIsFloatLitVarIsComplexLit"
+const _Operation_name = "InvalidNopNotAndOrVarIsConstVarIsPureVarIsStringLitVarIsRuneLitVarIsIntLitVarIsFloatLitVarIsComplexLitVarCallsVarRefersToPackageVarStructTagHasVarStructTagEqVarStructTagHasOptionMatchHasCommentMatchFuncHasDocMatchIsSuppressedPkgHasMethodPkgReferencesDeclKindIsDeclIsExportedDeclPropEqDeclPropMatches"
 
-var _Operation_index = [...]uint8{0, 7, 10, 13, 16, 18, 28, 37, 51, 63, 74, 87, 102}
+var _Operation_index = [...]uint16{0, 7, 10, 13, 16, 18, 28, 37, 51, 63, 74, 87, 102, 110, 128, 143, 157, 178, 193, 208, 225, 237, 250, 260, 274, 284, 299}
 
 func (i Operation) String() string {
 	if i < 0 || i >= Operation(len(_Operation_index)-1) {
@@ -0,0 +1,94 @@
+// Package goversion implements the Go language version helpers.
+//
+// The versions are represented as "1.N" strings; an empty string
+// means that the version is unknown and it compares less than
+// any other version.
+package goversion
+
+import (
+	"go/build/constraint"
+	"strconv"
+	"strings"
+)
+
+// Lang returns the language version part of v, like "1.21" for "go1.21.3".
+// An empty string is returned if v is not a valid Go version.
+func Lang(v string) string {
+	v = strings.TrimPrefix(v, "go")
+	major, rest, ok := strings.Cut(v, ".")
+	if !ok || major != "1" {
+		return ""
+	}
+	minor := rest
+	if i := strings.IndexFunc(minor, func(ch rune) bool { return ch < '0' || ch > '9' }); i != -1 {
+		minor = minor[:i]
+	}
+	if minor == "" {
+		return ""
+	}
+	n, err := strconv.Atoi(minor)
+	if err != nil {
+		return ""
+	}
+	return "1." + strconv.Itoa(n)
+}
+
+// Compare returns -1, 0 or +1 depending on whether x < y, x == y, or x > y.
+// Both x and y should be normalized with Lang.
+func Compare(x, y string) int {
+	xv, yv := minor(x), minor(y)
+	switch {
+	case xv < yv:
+		return -1
+	case xv > yv:
+		return +1
+	default:
+		return 0
+	}
+}
+
+// Max returns the newest version of x and y.
+func Max(x, y string) string {
+	if Compare(x, y) >= 0 {
+		return x
+	}
+	return y
+}
+
+// FromConstraint returns the minimal Go version that is required
+// by the build constraint expression, like "1.21" for "go1.21 && linux".
+// An empty string is returned if there is no such requirement.
+func FromConstraint(x constraint.Expr) string {
+	switch x := x.(type) {
+	case *constraint.TagExpr:
+		if strings.HasPrefix(x.Tag, "go1.") {
+			return Lang(x.Tag)
+		}
+		return ""
+	case *constraint.AndExpr:
+		return Max(FromConstraint(x.X), FromConstraint(x.Y))
+	case *constraint.OrExpr:
+		v1 := FromConstraint(x.X)
+		v2 := FromConstraint(x.Y)
+		if v1 == "" || v2 == "" {
+			return ""
+		}
+		if Compare(v1, v2) <= 0 {
+			return v1
+		}
+		return v2
+	default:
+		return ""
+	}
+}
+
+func minor(v string) int {
+	if v == "" {
+		return -1
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(v, "1."))
+	if err != nil {
+		return -1
+	}
+	return n
+}
@@ -0,0 +1,74 @@
+package goversion
+
+import (
+	"go/build/constraint"
+	"testing"
+)
+
+func TestLang(t *testing.T) {
+	tests := []struct {
+		v    string
+		want string
+	}{
+		{"1.21", "1.21"},
+		{"go1.21", "1.21"},
+		{"1.21.3", "1.21"},
+		{"go1.22rc1", "1.22"},
+		{"1.9", "1.9"},
+		{"1.09", "1.9"},
+		{"2.0", ""},
+		{"1", ""},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if have := Lang(test.v); have != test.want {
+			t.Errorf("Lang(%q):\nhave: %s\nwant: %s", test.v, have, test.want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		x, y string
+		want int
+	}{
+		{"1.21", "1.21", 0},
+		{"1.9", "1.21", -1},
+		{"1.21", "1.9", +1},
+		{"", "1.9", -1},
+		{"1.9", "", +1},
+		{"", "", 0},
+	}
+
+	for _, test := range tests {
+		if have := Compare(test.x, test.y); have != test.want {
+			t.Errorf("Compare(%q, %q): have %d, want %d", test.x, test.y, have, test.want)
+		}
+	}
+}
+
+func TestFromConstraint(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"linux", ""},
+		{"go1.21", "1.21"},
+		{"linux && go1.18", "1.18"},
+		{"go1.18 && go1.20", "1.20"},
+		{"go1.18 || go1.20", "1.18"},
+		{"go1.18 || linux", ""},
+		{"!go1.18", ""},
+	}
+
+	for _, test := range tests {
+		x, err := constraint.Parse("//go:build " + test.expr)
+		if err != nil {
+			t.Fatalf("parse %q: %v", test.expr, err)
+		}
+		if have := FromConstraint(x); have != test.want {
+			t.Errorf("FromConstraint(%q):\nhave: %s\nwant: %s", test.expr, have, test.want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Package imports resolves package references through a file import table.
+//
+// It doesn't do any type checking, so the results are only as good
+// as the syntax allows: an identifier that is declared inside the file
+// is never treated as a package reference, everything else is resolved
+// via the file imports (including the aliased and dot imports).
+package imports
+
+import (
+	"go/ast"
+	"strconv"
+	"strings"
+)
+
+// Table maps the file-local package names to their import paths.
+type Table struct {
+	names map[string]string
+	dots  []string
+}
+
+func NewTable(f *ast.File) *Table {
+	t := &Table{names: make(map[string]string, len(f.Imports))}
+	for _, imp := range f.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		if imp.Name == nil {
+			t.names[PkgName(path)] = path
+			continue
+		}
+		switch imp.Name.Name {
+		case "_":
+			// Can't be referenced.
+		case ".":
+			t.dots = append(t.dots, path)
+		default:
+			t.names[imp.Name.Name] = path
+		}
+	}
+	return t
+}
+
+// PkgPath returns the import path of the package referenced by ident.
+// An empty string is returned if ident is not a package reference.
+func (t *Table) PkgPath(ident *ast.Ident) string {
+	if ident.Obj != nil {
+		// Shadowed by some local declaration.
+		return ""
+	}
+	return t.names[ident.Name]
+}
+
+// IsDotImported reports whether pkgPath is imported with a "." name.
+func (t *Table) IsDotImported(pkgPath string) bool {
+	for _, p := range t.dots {
+		if p == pkgPath {
+			return true
+		}
+	}
+	return false
+}
+
+// RefersToSymbol reports whether e refers to the pkgPath.name symbol.
+//
+// The e is expected to be a qualified identifier like `http.Get`
+// or a plain identifier for the dot-imported packages.
+// Parenthesized and generic instantiation expressions are unwrapped.
+func (t *Table) RefersToSymbol(e ast.Expr, pkgPath, name string) bool {
+	switch e := e.(type) {
+	case *ast.ParenExpr:
+		return t.RefersToSymbol(e.X, pkgPath, name)
+	case *ast.IndexExpr:
+		return t.RefersToSymbol(e.X, pkgPath, name)
+	case *ast.IndexListExpr:
+		return t.RefersToSymbol(e.X, pkgPath, name)
+	case *ast.SelectorExpr:
+		x, ok := e.X.(*ast.Ident)
+		return ok && e.Sel.Name == name && t.PkgPath(x) == pkgPath
+	case *ast.Ident:
+		return e.Obj == nil && e.Name == name && t.IsDotImported(pkgPath)
+	default:
+		return false
+	}
+}
+
+// RefersToPackage reports whether e is a reference to the pkgPath package
+// or a qualified identifier that belongs to it.
+func (t *Table) RefersToPackage(e ast.Expr, pkgPath string) bool {
+	switch e := e.(type) {
+	case *ast.ParenExpr:
+		return t.RefersToPackage(e.X, pkgPath)
+	case *ast.StarExpr:
+		return t.RefersToPackage(e.X, pkgPath)
+	case *ast.SelectorExpr:
+		return t.RefersToPackage(e.X, pkgPath)
+	case *ast.Ident:
+		return t.PkgPath(e) == pkgPath
+	default:
+		return false
+	}
+}
+
+// PkgName returns the default package name for the given import path.
+//
+// It follows the common conventions: major version suffixes are ignored,
+// gopkg.in-style ".vN" suffixes and "go-" prefixes are stripped.
+func PkgName(path string) string {
+	parts := strings.Split(path, "/")
+	name := parts[len(parts)-1]
+	if len(parts) > 1 && isMajorVersion(name) {
+		name = parts[len(parts)-2]
+	}
+	if i := strings.Index(name, ".v"); i != -1 && isMajorVersion(name[i+1:]) {
+		name = name[:i]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.TrimSuffix(name, "-go")
+	return strings.ReplaceAll(name, "-", "_")
+}
+
+// SplitSymbol splits a "pkgpath.Name" string into its parts.
+func SplitSymbol(s string) (pkgPath, name string, ok bool) {
+	slash := strings.LastIndexByte(s, '/')
+	dot := strings.LastIndexByte(s, '.')
+	if dot <= slash || dot == len(s)-1 {
+		return "", "", false
+	}
+	return s[:dot], s[dot+1:], true
+}
+
+// MatchPattern reports whether the import path matches the pattern.
+//
+// Like in the go command, a "/..." suffix matches the path itself
+// and all paths below it; otherwise the path should be identical.
+func MatchPattern(pattern, path string) bool {
+	if prefix, ok := cutSuffix(pattern, "/..."); ok {
+		return path == prefix || strings.HasPrefix(path, prefix+"/")
+	}
+	return pattern == path
+}
+
+func cutSuffix(s, suffix string) (string, bool) {
+	if !strings.HasSuffix(s, suffix) {
+		return s, false
+	}
+	return s[:len(s)-len(suffix)], true
+}
+
+func isMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	for _, ch := range s[1:] {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+	return true
+}
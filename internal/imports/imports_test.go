@@ -0,0 +1,167 @@
+package imports
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestPkgName(t *testing.T) {
+	tests := []struct {
+		path string
+		name string
+	}{
+		{"fmt", "fmt"},
+		{"net/http", "http"},
+		{"github.com/jackc/pgx/v4", "pgx"},
+		{"gopkg.in/yaml.v3", "yaml"},
+		{"github.com/mattn/go-isatty", "isatty"},
+		{"github.com/foo/bar-go", "bar"},
+	}
+
+	for _, test := range tests {
+		if have := PkgName(test.path); have != test.name {
+			t.Errorf("PkgName(%q):\nhave: %s\nwant: %s", test.path, have, test.name)
+		}
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"net/http", "net/http", true},
+		{"net/http", "net/http/httptest", false},
+		{"net/http/...", "net/http", true},
+		{"net/http/...", "net/http/httptest", true},
+		{"net/http/...", "net/httpx", false},
+		{"golang.org/x/tools/...", "golang.org/x/tools/gopls/internal", true},
+		{"golang.org/x/tools/...", "golang.org/x/toolsmith", false},
+	}
+
+	for _, test := range tests {
+		if have := MatchPattern(test.pattern, test.path); have != test.want {
+			t.Errorf("MatchPattern(%q, %q):\nhave: %v\nwant: %v", test.pattern, test.path, have, test.want)
+		}
+	}
+}
+
+func TestRefersToSymbol(t *testing.T) {
+	const src = `package example
+import (
+	nethttp "net/http"
+	. "strings"
+	"io"
+)
+func f(http int) {
+	nethttp.Get("")
+	http.Get("")
+	Contains("", "")
+	io.Copy(nil, nil)
+	(io.ReadAll)(nil)
+	{
+		io := 10
+		io.Copy(nil, nil)
+	}
+}
+`
+
+	tests := []struct {
+		symbol string
+		want   []bool
+	}{
+		{"net/http.Get", []bool{true, false, false, false, false, false}},
+		{"strings.Contains", []bool{false, false, true, false, false, false}},
+		{"io.Copy", []bool{false, false, false, true, false, false}},
+		{"io.ReadAll", []bool{false, false, false, false, true, false}},
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "example.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := NewTable(f)
+	var calls []*ast.CallExpr
+	ast.Inspect(f, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			calls = append(calls, call)
+		}
+		return true
+	})
+
+	for _, test := range tests {
+		pkgPath, name, ok := SplitSymbol(test.symbol)
+		if !ok {
+			t.Fatalf("can't split %q", test.symbol)
+		}
+		var have []bool
+		for _, call := range calls {
+			have = append(have, table.RefersToSymbol(call.Fun, pkgPath, name))
+		}
+		if fmt.Sprint(have) != fmt.Sprint(test.want) {
+			t.Errorf("%s results mismatch:\nhave: %v\nwant: %v", test.symbol, have, test.want)
+		}
+	}
+}
+
+func TestRefersToPackage(t *testing.T) {
+	const src = `package example
+import (
+	nethttp "net/http"
+	"github.com/jackc/pgx/v4"
+	"gopkg.in/yaml.v3"
+	"io"
+)
+func f() {
+	nethttp.Get("")
+	http.Get("")
+	pgx.Connect(nil, "")
+	yaml.Marshal(nil)
+	(*io.Reader)(nil)
+	{
+		io := 10
+		io.Copy(nil, nil)
+	}
+}
+`
+
+	tests := []struct {
+		pkgPath string
+		want    []bool
+	}{
+		{"net/http", []bool{true, false, false, false, false, false}},
+		{"github.com/jackc/pgx/v4", []bool{false, false, true, false, false, false}},
+		{"github.com/jackc/pgx", []bool{false, false, false, false, false, false}},
+		{"gopkg.in/yaml.v3", []bool{false, false, false, true, false, false}},
+		{"io", []bool{false, false, false, false, true, false}},
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "example.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := NewTable(f)
+	var calls []*ast.CallExpr
+	ast.Inspect(f, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			calls = append(calls, call)
+		}
+		return true
+	})
+
+	for _, test := range tests {
+		var have []bool
+		for _, call := range calls {
+			have = append(have, table.RefersToPackage(call.Fun, test.pkgPath))
+		}
+		if fmt.Sprint(have) != fmt.Sprint(test.want) {
+			t.Errorf("%s results mismatch:\nhave: %v\nwant: %v", test.pkgPath, have, test.want)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+// Package nodekind enumerates the go/ast node types.
+//
+// The node kinds are used to describe the AST contents of a file
+// compactly, so the files that can't match a pattern are skipped
+// without being parsed.
+package nodekind
+
+import (
+	"go/ast"
+	"strconv"
+)
+
+// Kind is a go/ast node type identifier.
+type Kind uint8
+
+// The kinds are encoded into the corpus metadata,
+// so the new kinds should only be added to the end of this list.
+const (
+	Invalid Kind = iota
+	ArrayType
+	AssignStmt
+	BadDecl
+	BadExpr
+	BadStmt
+	BasicLit
+	BinaryExpr
+	BlockStmt
+	BranchStmt
+	CallExpr
+	CaseClause
+	ChanType
+	CommClause
+	Comment
+	CommentGroup
+	CompositeLit
+	DeclStmt
+	DeferStmt
+	Ellipsis
+	EmptyStmt
+	ExprStmt
+	Field
+	FieldList
+	File
+	ForStmt
+	FuncDecl
+	FuncLit
+	FuncType
+	GenDecl
+	GoStmt
+	Ident
+	IfStmt
+	ImportSpec
+	IncDecStmt
+	IndexExpr
+	IndexListExpr
+	InterfaceType
+	KeyValueExpr
+	LabeledStmt
+	MapType
+	Package
+	ParenExpr
+	RangeStmt
+	ReturnStmt
+	SelectStmt
+	SelectorExpr
+	SendStmt
+	SliceExpr
+	StarExpr
+	StructType
+	SwitchStmt
+	TypeAssertExpr
+	TypeSpec
+	TypeSwitchStmt
+	UnaryExpr
+	ValueSpec
+
+	// NumKinds is the number of the known kinds (including Invalid).
+	NumKinds
+)
+
+// Of returns the n node kind.
+// Invalid is returned for the unknown node types.
+func Of(n ast.Node) Kind {
+	switch n.(type) {
+	case *ast.ArrayType:
+		return ArrayType
+	case *ast.AssignStmt:
+		return AssignStmt
+	case *ast.BadDecl:
+		return BadDecl
+	case *ast.BadExpr:
+		return BadExpr
+	case *ast.BadStmt:
+		return BadStmt
+	case *ast.BasicLit:
+		return BasicLit
+	case *ast.BinaryExpr:
+		return BinaryExpr
+	case *ast.BlockStmt:
+		return BlockStmt
+	case *ast.BranchStmt:
+		return BranchStmt
+	case *ast.CallExpr:
+		return CallExpr
+	case *ast.CaseClause:
+		return CaseClause
+	case *ast.ChanType:
+		return ChanType
+	case *ast.CommClause:
+		return CommClause
+	case *ast.Comment:
+		return Comment
+	case *ast.CommentGroup:
+		return CommentGroup
+	case *ast.CompositeLit:
+		return CompositeLit
+	case *ast.DeclStmt:
+		return DeclStmt
+	case *ast.DeferStmt:
+		return DeferStmt
+	case *ast.Ellipsis:
+		return Ellipsis
+	case *ast.EmptyStmt:
+		return EmptyStmt
+	case *ast.ExprStmt:
+		return ExprStmt
+	case *ast.Field:
+		return Field
+	case *ast.FieldList:
+		return FieldList
+	case *ast.File:
+		return File
+	case *ast.ForStmt:
+		return ForStmt
+	case *ast.FuncDecl:
+		return FuncDecl
+	case *ast.FuncLit:
+		return FuncLit
+	case *ast.FuncType:
+		return FuncType
+	case *ast.GenDecl:
+		return GenDecl
+	case *ast.GoStmt:
+		return GoStmt
+	case *ast.Ident:
+		return Ident
+	case *ast.IfStmt:
+		return IfStmt
+	case *ast.ImportSpec:
+		return ImportSpec
+	case *ast.IncDecStmt:
+		return IncDecStmt
+	case *ast.IndexExpr:
+		return IndexExpr
+	case *ast.IndexListExpr:
+		return IndexListExpr
+	case *ast.InterfaceType:
+		return InterfaceType
+	case *ast.KeyValueExpr:
+		return KeyValueExpr
+	case *ast.LabeledStmt:
+		return LabeledStmt
+	case *ast.MapType:
+		return MapType
+	case *ast.Package:
+		return Package
+	case *ast.ParenExpr:
+		return ParenExpr
+	case *ast.RangeStmt:
+		return RangeStmt
+	case *ast.ReturnStmt:
+		return ReturnStmt
+	case *ast.SelectStmt:
+		return SelectStmt
+	case *ast.SelectorExpr:
+		return SelectorExpr
+	case *ast.SendStmt:
+		return SendStmt
+	case *ast.SliceExpr:
+		return SliceExpr
+	case *ast.StarExpr:
+		return StarExpr
+	case *ast.StructType:
+		return StructType
+	case *ast.SwitchStmt:
+		return SwitchStmt
+	case *ast.TypeAssertExpr:
+		return TypeAssertExpr
+	case *ast.TypeSpec:
+		return TypeSpec
+	case *ast.TypeSwitchStmt:
+		return TypeSwitchStmt
+	case *ast.UnaryExpr:
+		return UnaryExpr
+	case *ast.ValueSpec:
+		return ValueSpec
+	default:
+		return Invalid
+	}
+}
+
+func (k Kind) String() string {
+	if s := kindNames[k]; s != "" {
+		return s
+	}
+	return "Invalid"
+}
+
+var kindNames = [NumKinds]string{
+	ArrayType:      "ArrayType",
+	AssignStmt:     "AssignStmt",
+	BadDecl:        "BadDecl",
+	BadExpr:        "BadExpr",
+	BadStmt:        "BadStmt",
+	BasicLit:       "BasicLit",
+	BinaryExpr:     "BinaryExpr",
+	BlockStmt:      "BlockStmt",
+	BranchStmt:     "BranchStmt",
+	CallExpr:       "CallExpr",
+	CaseClause:     "CaseClause",
+	ChanType:       "ChanType",
+	CommClause:     "CommClause",
+	Comment:        "Comment",
+	CommentGroup:   "CommentGroup",
+	CompositeLit:   "CompositeLit",
+	DeclStmt:       "DeclStmt",
+	DeferStmt:      "DeferStmt",
+	Ellipsis:       "Ellipsis",
+	EmptyStmt:      "EmptyStmt",
+	ExprStmt:       "ExprStmt",
+	Field:          "Field",
+	FieldList:      "FieldList",
+	File:           "File",
+	ForStmt:        "ForStmt",
+	FuncDecl:       "FuncDecl",
+	FuncLit:        "FuncLit",
+	FuncType:       "FuncType",
+	GenDecl:        "GenDecl",
+	GoStmt:         "GoStmt",
+	Ident:          "Ident",
+	IfStmt:         "IfStmt",
+	ImportSpec:     "ImportSpec",
+	IncDecStmt:     "IncDecStmt",
+	IndexExpr:      "IndexExpr",
+	IndexListExpr:  "IndexListExpr",
+	InterfaceType:  "InterfaceType",
+	KeyValueExpr:   "KeyValueExpr",
+	LabeledStmt:    "LabeledStmt",
+	MapType:        "MapType",
+	Package:        "Package",
+	ParenExpr:      "ParenExpr",
+	RangeStmt:      "RangeStmt",
+	ReturnStmt:     "ReturnStmt",
+	SelectStmt:     "SelectStmt",
+	SelectorExpr:   "SelectorExpr",
+	SendStmt:       "SendStmt",
+	SliceExpr:      "SliceExpr",
+	StarExpr:       "StarExpr",
+	StructType:     "StructType",
+	SwitchStmt:     "SwitchStmt",
+	TypeAssertExpr: "TypeAssertExpr",
+	TypeSpec:       "TypeSpec",
+	TypeSwitchStmt: "TypeSwitchStmt",
+	UnaryExpr:      "UnaryExpr",
+	ValueSpec:      "ValueSpec",
+}
+
+// Set is a bitset of the node kinds.
+type Set uint64
+
+// Add adds k to the set.
+func (s *Set) Add(k Kind) { *s |= 1 << k }
+
+// Has reports whether k is in the set.
+func (s Set) Has(k Kind) bool { return s&(1<<k) != 0 }
+
+// Contains reports whether all other set kinds are in s.
+func (s Set) Contains(other Set) bool { return s&other == other }
+
+// Kinds returns a list of the set members.
+func (s Set) Kinds() []Kind {
+	var result []Kind
+	for k := Kind(0); k < NumKinds; k++ {
+		if s.Has(k) {
+			result = append(result, k)
+		}
+	}
+	return result
+}
+
+// Encode returns a compact set representation suitable for JSON.
+// We can't use JSON numbers here as they can't hold all 64 bits.
+func (s Set) Encode() string {
+	return strconv.FormatUint(uint64(s), 16)
+}
+
+// Decode parses the Encode result.
+func Decode(s string) (Set, error) {
+	v, err := strconv.ParseUint(s, 16, 64)
+	return Set(v), err
+}
+
+// FileKinds returns the set of the node kinds that are present inside f.
+func FileKinds(f *ast.File) Set {
+	var set Set
+	ast.Inspect(f, func(n ast.Node) bool {
+		if n != nil {
+			set.Add(Of(n))
+		}
+		return true
+	})
+	return set
+}
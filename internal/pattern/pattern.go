@@ -0,0 +1,210 @@
+// Package pattern extracts the static info from the gogrep patterns.
+//
+// The pattern is parsed in the same way as gogrep does it,
+// but we don't have access to the gogrep internal representation,
+// so every analysis here is conservative: when in doubt,
+// it reports less than it could.
+package pattern
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/quasilyte/gocorpus/internal/nodekind"
+)
+
+// wildName is used as a placeholder for the $-variables.
+const wildName = "gogrep_wild"
+
+var varRegexp = regexp.MustCompile(`\$\*?[\pL_][\pL\pN_]*`)
+
+// parse returns the pattern AST roots.
+// A nil result is returned for the unrecognized patterns.
+func parse(src string) []ast.Node {
+	src = strings.TrimSpace(varRegexp.ReplaceAllString(src, wildName))
+	if src == "" {
+		return nil
+	}
+
+	// The parsing order follows the gogrep parseDetectingNode.
+
+	if strings.HasPrefix(src, "range ") {
+		if stmts := parseStmts("for " + src + " {}"); len(stmts) == 1 {
+			return stmts
+		}
+	}
+	if strings.HasPrefix(src, "for ") && !strings.HasSuffix(src, "}") {
+		if stmts := parseStmts(src + "{}"); len(stmts) == 1 {
+			if _, ok := stmts[0].(*ast.RangeStmt); ok {
+				return stmts
+			}
+		}
+	}
+
+	if f := parseFile("package p; func _() { if true " + src + " else {} }"); f != nil {
+		body := f.Decls[0].(*ast.FuncDecl).Body
+		if len(body.List) == 1 {
+			return []ast.Node{body.List[0].(*ast.IfStmt).Body}
+		}
+	}
+
+	if f := parseFile("package p; var _ = []interface{}{ " + src + ", }"); f != nil {
+		spec := f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.ValueSpec)
+		var result []ast.Node
+		for _, elt := range spec.Values[0].(*ast.CompositeLit).Elts {
+			result = append(result, elt)
+		}
+		return result
+	}
+
+	if stmts := parseStmts(src); stmts != nil {
+		return stmts
+	}
+
+	if f := parseFile("package p; " + src); f != nil && len(f.Decls) != 0 {
+		var result []ast.Node
+		for _, decl := range f.Decls {
+			result = append(result, decl)
+		}
+		return result
+	}
+
+	return nil
+}
+
+func parseStmts(src string) []ast.Node {
+	f := parseFile("package p; func _() { " + src + " }")
+	if f == nil {
+		return nil
+	}
+	var result []ast.Node
+	for _, stmt := range f.Decls[0].(*ast.FuncDecl).Body.List {
+		result = append(result, stmt)
+	}
+	return result
+}
+
+func parseFile(src string) *ast.File {
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.SkipObjectResolution)
+	if err != nil || hasBadNodes(f) {
+		return nil
+	}
+	return f
+}
+
+func hasBadNodes(root ast.Node) bool {
+	found := false
+	ast.Inspect(root, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.BadExpr, *ast.BadStmt, *ast.BadDecl:
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// requirableKinds are the node kinds that can only be matched
+// by the nodes of the same kind.
+//
+// Some kinds are excluded on purpose:
+//   - InterfaceType: `interface{}` matches `any` in non-strict mode
+//   - BlockStmt, ExprStmt: the statement-level $-variables and the parser wrappers
+//   - DeclStmt, GenDecl and specs: a var decl pattern matches both local and global decls
+//   - Ident, BasicLit: they're everywhere anyway
+var requirableKinds = func() nodekind.Set {
+	var set nodekind.Set
+	kinds := []nodekind.Kind{
+		nodekind.ArrayType,
+		nodekind.AssignStmt,
+		nodekind.BinaryExpr,
+		nodekind.BranchStmt,
+		nodekind.CallExpr,
+		nodekind.CaseClause,
+		nodekind.ChanType,
+		nodekind.CommClause,
+		nodekind.CompositeLit,
+		nodekind.DeferStmt,
+		nodekind.Ellipsis,
+		nodekind.ForStmt,
+		nodekind.FuncDecl,
+		nodekind.FuncLit,
+		nodekind.FuncType,
+		nodekind.GoStmt,
+		nodekind.IfStmt,
+		nodekind.IncDecStmt,
+		nodekind.IndexExpr,
+		nodekind.IndexListExpr,
+		nodekind.KeyValueExpr,
+		nodekind.LabeledStmt,
+		nodekind.MapType,
+		nodekind.ParenExpr,
+		nodekind.RangeStmt,
+		nodekind.ReturnStmt,
+		nodekind.SelectStmt,
+		nodekind.SelectorExpr,
+		nodekind.SendStmt,
+		nodekind.SliceExpr,
+		nodekind.StarExpr,
+		nodekind.StructType,
+		nodekind.SwitchStmt,
+		nodekind.TypeAssertExpr,
+		nodekind.TypeSwitchStmt,
+		nodekind.UnaryExpr,
+	}
+	for _, k := range kinds {
+		set.Add(k)
+	}
+	return set
+}()
+
+// RequiredIdents returns a sorted list of identifier names that
+// must be present in a file for the pattern to match anything inside it.
+func RequiredIdents(src string) []string {
+	set := make(map[string]struct{})
+	for _, root := range parse(src) {
+		ast.Inspect(root, func(n ast.Node) bool {
+			ident, ok := n.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			switch ident.Name {
+			case wildName, "_":
+				// Not a concrete identifier.
+			case "any":
+				// Matches `interface{}` in non-strict mode.
+			default:
+				set[ident.Name] = struct{}{}
+			}
+			return true
+		})
+	}
+	result := make([]string, 0, len(set))
+	for name := range set {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// RequiredNodeKinds returns a set of node kinds that must be present
+// in a file for the pattern to match anything inside it.
+func RequiredNodeKinds(src string) nodekind.Set {
+	var set nodekind.Set
+	for _, root := range parse(src) {
+		ast.Inspect(root, func(n ast.Node) bool {
+			if n == nil {
+				return true
+			}
+			if k := nodekind.Of(n); requirableKinds.Has(k) {
+				set.Add(k)
+			}
+			return true
+		})
+	}
+	return set
+}
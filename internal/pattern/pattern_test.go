@@ -0,0 +1,62 @@
+package pattern
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRequiredIdents(t *testing.T) {
+	tests := []struct {
+		src    string
+		idents string
+	}{
+		{`$x`, `[]`},
+		{`$_ = $_`, `[]`},
+		{`_ = $x`, `[]`},
+		{`f($*_)`, `[f]`},
+		{`var $b bytes.Buffer; $*_; return $b.String()`, `[Buffer String bytes]`},
+		{`$m.Lock(); defer $m.Unlock()`, `[Lock Unlock]`},
+		{`func(x any) {}`, `[x]`},
+		{`"WriteString"`, `[]`},
+		{`{{{`, `[]`},
+	}
+
+	for _, test := range tests {
+		have := fmt.Sprint(RequiredIdents(test.src))
+		if have != test.idents {
+			t.Errorf("RequiredIdents(%q):\nhave: %s\nwant: %s", test.src, have, test.idents)
+		}
+	}
+}
+
+func TestRequiredNodeKinds(t *testing.T) {
+	tests := []struct {
+		src   string
+		kinds string
+	}{
+		{`$x`, `[]`},
+		{`$x; $y`, `[]`},
+		{`f($*_)`, `[CallExpr]`},
+		{`$x.Foo()`, `[CallExpr SelectorExpr]`},
+		{`var $b bytes.Buffer; $*_; return $b.String()`, `[CallExpr ReturnStmt SelectorExpr]`},
+		{`select { case <-$ch: $*_ }`, `[CommClause SelectStmt UnaryExpr]`},
+		{`{ $*_ }`, `[]`},
+		{`interface{}`, `[]`},
+		{`map[string]interface{}{}`, `[CompositeLit MapType]`},
+		{`for range $x { $*_ }`, `[RangeStmt]`},
+		{`range $x`, `[RangeStmt]`},
+		{`for $k, $v := range $x`, `[RangeStmt]`},
+		{`$x := <-$ch`, `[AssignStmt UnaryExpr]`},
+		{`go func() { $*_ }()`, `[CallExpr FuncLit FuncType GoStmt]`},
+		{`func $name($*_) error { $*_ }`, `[FuncDecl FuncType]`},
+		{`"$x" + 1`, `[BinaryExpr]`},
+		{`{{{`, `[]`},
+	}
+
+	for _, test := range tests {
+		have := fmt.Sprint(RequiredNodeKinds(test.src).Kinds())
+		if have != test.kinds {
+			t.Errorf("RequiredNodeKinds(%q):\nhave: %s\nwant: %s", test.src, have, test.kinds)
+		}
+	}
+}
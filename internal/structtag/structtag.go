@@ -0,0 +1,85 @@
+// Package structtag parses the struct field tags.
+//
+// The parsing rules follow the reflect.StructTag conventions:
+// a tag is a sequence of key:"value" pairs separated by spaces,
+// a value is a comma-separated list of a name and its options.
+package structtag
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Pair is a single key:"value" tag element.
+type Pair struct {
+	Key   string
+	Value string
+}
+
+// Lookup returns the value associated with the key in the tag.
+func Lookup(tag, key string) (string, bool) {
+	return reflect.StructTag(tag).Lookup(key)
+}
+
+// Parse returns all tag pairs in their original order.
+// Like reflect.StructTag.Lookup, it stops at the first malformed pair.
+func Parse(tag string) []Pair {
+	var pairs []Pair
+	for tag != "" {
+		tag = strings.TrimLeft(tag, " ")
+		if tag == "" {
+			break
+		}
+
+		// A key is a non-empty sequence of the non-control characters
+		// other than space, quote and colon.
+		i := 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			break
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		// Scan the quoted string to find the value.
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			break
+		}
+		value, err := strconv.Unquote(tag[:i+1])
+		if err != nil {
+			break
+		}
+		tag = tag[i+1:]
+		pairs = append(pairs, Pair{Key: key, Value: value})
+	}
+	return pairs
+}
+
+// Split splits the tag value into a name and its options.
+// For the "name,omitempty" value it returns "name" and ["omitempty"].
+func Split(value string) (name string, options []string) {
+	parts := strings.Split(value, ",")
+	return parts[0], parts[1:]
+}
+
+// HasOption reports whether the tag value contains the given option.
+// The name part of the value is never treated as an option.
+func HasOption(value, option string) bool {
+	_, options := Split(value)
+	for _, opt := range options {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
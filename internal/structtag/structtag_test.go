@@ -0,0 +1,51 @@
+package structtag
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{``, `[]`},
+		{`json:"name"`, `[{json name}]`},
+		{`json:"name,omitempty" yaml:"-"`, `[{json name,omitempty} {yaml -}]`},
+		{`  db:"id"   validate:"required,min=1"`, `[{db id} {validate required,min=1}]`},
+		{`json:"a\"b"`, `[{json a"b}]`},
+		{`json:"a" broken`, `[{json a}]`},
+		{`json:name`, `[]`},
+		{`:"x"`, `[]`},
+		{`json:"unterminated`, `[]`},
+	}
+
+	for _, test := range tests {
+		have := fmt.Sprint(Parse(test.tag))
+		if have != test.want {
+			t.Errorf("Parse(%q):\nhave: %s\nwant: %s", test.tag, have, test.want)
+		}
+	}
+}
+
+func TestHasOption(t *testing.T) {
+	tests := []struct {
+		value  string
+		option string
+		want   bool
+	}{
+		{"name,omitempty", "omitempty", true},
+		{",omitempty", "omitempty", true},
+		{"name,string,omitempty", "string", true},
+		{"omitempty", "omitempty", false},
+		{"name", "omitempty", false},
+		{"", "omitempty", false},
+	}
+
+	for _, test := range tests {
+		if have := HasOption(test.value, test.option); have != test.want {
+			t.Errorf("HasOption(%q, %q):\nhave: %v\nwant: %v", test.value, test.option, have, test.want)
+		}
+	}
+}
@@ -2,11 +2,18 @@ package main
 
 import (
 	"go/ast"
+	"go/build/constraint"
 	"strconv"
 	"strings"
+
+	"github.com/quasilyte/gocorpus/internal/buildtags"
+	"github.com/quasilyte/gocorpus/internal/goversion"
+	"github.com/quasilyte/gocorpus/internal/nodekind"
 )
 
 type repositoryFileInfo struct {
+	pkgName string
+
 	isTest         bool
 	isAutogen      bool
 	isMain         bool
@@ -14,23 +21,73 @@ type repositoryFileInfo struct {
 	importsUnsafe  bool
 	importsReflect bool
 	maxDepth       int
+	nodeKinds      nodekind.Set
+
+	usesGenericDecls  bool
+	usesGenericInst   bool
+	usesGoroutines    bool
+	usesSelect        bool
+	usesDefer         bool
+	usesLabeledBranch bool
+	usesGoto          bool
+	usesMethodValues  bool
+	usesRangeFunc     bool
+	usesTypeSwitch    bool
+	usesEmbedding     bool
+
+	// mayUseGenericInst, mayUseMethodValues and mayUseRangeFunc
+	// are the conservative counterparts of the uses* fields.
+	mayUseGenericInst  bool
+	mayUseMethodValues bool
+	mayUseRangeFunc    bool
+
+	// idents is a set of all identifier names used in the file.
+	idents map[string]struct{}
+
+	// directives maps a //go: directive name to the number of its occurrences.
+	directives map[string]int
+
+	buildConstraint string
+	filenameTags    []string
+
+	// buildGoVersion is a minimal Go version required by the build constraint.
+	buildGoVersion string
 }
 
 func analyzeFile(filename string, f *ast.File, src []byte) *repositoryFileInfo {
-	info := &repositoryFileInfo{}
+	info := &repositoryFileInfo{
+		idents: make(map[string]struct{}),
+	}
 
 	info.isTest = strings.HasSuffix(f.Name.String(), "_test") ||
 		strings.HasSuffix(filename, "_test.go")
 
-	info.isMain = f.Name.String() == "main"
+	info.pkgName = f.Name.String()
+	info.isMain = info.pkgName == "main"
+
+	if x := fileBuildConstraint(f); x != nil {
+		info.buildConstraint = x.String()
+		info.buildGoVersion = goversion.FromConstraint(x)
+	}
+	info.filenameTags = buildtags.FilenameTags(filename)
 
 	for _, comment := range f.Comments {
-		if isAutogenComment(comment) {
+		if !info.isAutogen && isAutogenComment(comment) {
 			info.isAutogen = true
-			break
+		}
+		for _, c := range comment.List {
+			name, ok := parseDirective(c.Text)
+			if !ok {
+				continue
+			}
+			if info.directives == nil {
+				info.directives = make(map[string]int)
+			}
+			info.directives[name]++
 		}
 	}
 
+	features := newFeatureScanner(info, f)
 	depth := 0
 	ast.Inspect(f, func(n ast.Node) bool {
 		if n == nil {
@@ -40,6 +97,11 @@ func analyzeFile(filename string, f *ast.File, src []byte) *repositoryFileInfo {
 			if depth > info.maxDepth {
 				info.maxDepth = depth
 			}
+			info.nodeKinds.Add(nodekind.Of(n))
+			if ident, ok := n.(*ast.Ident); ok {
+				info.idents[ident.Name] = struct{}{}
+			}
+			features.Visit(n)
 		}
 		return true
 	})
@@ -62,6 +124,53 @@ func analyzeFile(filename string, f *ast.File, src []byte) *repositoryFileInfo {
 	return info
 }
 
+// fileBuildConstraint returns the build constraint expression of f.
+// The legacy "// +build" lines are used only if there is no "//go:build" line.
+func fileBuildConstraint(f *ast.File) constraint.Expr {
+	var plusBuild constraint.Expr
+	for _, group := range f.Comments {
+		if group.Pos() >= f.Package {
+			break
+		}
+		for _, c := range group.List {
+			switch {
+			case constraint.IsGoBuild(c.Text):
+				x, err := constraint.Parse(c.Text)
+				if err != nil {
+					return nil
+				}
+				return x
+			case constraint.IsPlusBuild(c.Text):
+				x, err := constraint.Parse(c.Text)
+				if err != nil {
+					continue
+				}
+				if plusBuild == nil {
+					plusBuild = x
+				} else {
+					plusBuild = &constraint.AndExpr{X: plusBuild, Y: x}
+				}
+			}
+		}
+	}
+	return plusBuild
+}
+
+// parseDirective returns a directive name for the //go:name comments.
+func parseDirective(text string) (string, bool) {
+	if !strings.HasPrefix(text, "//go:") {
+		return "", false
+	}
+	name := text[len("//go:"):]
+	if i := strings.IndexAny(name, " \t"); i != -1 {
+		name = name[:i]
+	}
+	if name == "" {
+		return "", false
+	}
+	return name, true
+}
+
 func isAutogenComment(comment *ast.CommentGroup) bool {
 	generated := false
 	doNotEdit := false
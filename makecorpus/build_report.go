@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/quasilyte/gocorpus/internal/filebits"
+)
+
+// buildReport is a machine-readable makecorpus run summary.
+// It's written next to the corpus.json as build-report.json.
+type buildReport struct {
+	Version      int                `json:"version"`
+	Duration     durationMillis     `json:"duration_ms"`
+	Totals       buildReportTotals  `json:"totals"`
+	Repositories []*repoBuildReport `json:"repositories"`
+}
+
+type buildReportTotals struct {
+	Repositories       int            `json:"repositories"`
+	FailedRepositories int            `json:"failed_repositories"`
+	Files              int            `json:"files"`
+	SkippedFiles       int            `json:"skipped_files"`
+	SLOC               int            `json:"sloc"`
+	RawBytes           int            `json:"raw_bytes"`
+	MinifiedBytes      int            `json:"minified_bytes"`
+	FilesByFlag        map[string]int `json:"files_by_flag"`
+	MaxFileDepth       int64          `json:"max_file_depth"`
+	AvgFileDepth       int64          `json:"avg_file_depth"`
+	Warnings           int64          `json:"warnings"`
+	Errors             int64          `json:"errors"`
+}
+
+type repoBuildReport struct {
+	Name string `json:"name"`
+
+	// Status is one of "built", "reused" or "failed".
+	Status string `json:"status"`
+
+	Timings struct {
+		// Fetch is a time spent on getting the sources (e.g. git clone).
+		Fetch durationMillis `json:"fetch_ms"`
+		// Walk is a time spent on reading, parsing and analyzing the files.
+		Walk durationMillis `json:"walk_ms"`
+		// Minify is a time spent on the sources minification.
+		Minify durationMillis `json:"minify_ms"`
+		// Archive is a time spent on writing the output archives and indexes.
+		Archive durationMillis `json:"archive_ms"`
+	} `json:"timings"`
+
+	Files         int                 `json:"files"`
+	SLOC          int                 `json:"sloc"`
+	RawBytes      int                 `json:"raw_bytes"`
+	MinifiedBytes int                 `json:"minified_bytes"`
+	FilesByFlag   map[string]int      `json:"files_by_flag,omitempty"`
+	SkippedFiles  []skippedFileReport `json:"skipped_files,omitempty"`
+	Warnings      []string            `json:"warnings,omitempty"`
+	Errors        []string            `json:"errors,omitempty"`
+}
+
+type skippedFileReport struct {
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+func (r *repoBuildReport) addSkippedFile(f SkippedFileMeta) {
+	r.SkippedFiles = append(r.SkippedFiles, skippedFileReport{Name: f.Name, Error: f.Error})
+}
+
+// durationMillis is a time.Duration that is encoded as milliseconds.
+type durationMillis time.Duration
+
+func (d durationMillis) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, time.Duration(d).Milliseconds(), 10), nil
+}
+
+var fileFlagNames = []struct {
+	name string
+	mask int
+}{
+	{"IsTest", filebits.IsTest},
+	{"IsAutogen", filebits.IsAutogen},
+	{"IsMain", filebits.IsMain},
+	{"ImportsC", filebits.ImportsC},
+	{"ImportsUnsafe", filebits.ImportsUnsafe},
+	{"ImportsReflect", filebits.ImportsReflect},
+	{"UsesGenericDecls", filebits.UsesGenericDecls},
+	{"UsesGenericInst", filebits.UsesGenericInst},
+	{"UsesGoroutines", filebits.UsesGoroutines},
+	{"UsesSelect", filebits.UsesSelect},
+	{"UsesDefer", filebits.UsesDefer},
+	{"UsesLabeledBranch", filebits.UsesLabeledBranch},
+	{"UsesGoto", filebits.UsesGoto},
+	{"UsesMethodValues", filebits.UsesMethodValues},
+	{"UsesRangeFunc", filebits.UsesRangeFunc},
+	{"UsesTypeSwitch", filebits.UsesTypeSwitch},
+	{"UsesEmbedding", filebits.UsesEmbedding},
+	{"IsDuplicate", filebits.IsDuplicate},
+	{"MayUseGenericInst", filebits.MayUseGenericInst},
+	{"MayUseMethodValues", filebits.MayUseMethodValues},
+	{"MayUseRangeFunc", filebits.MayUseRangeFunc},
+}
+
+// setMeta fills the report with the repository metadata stats.
+// A nil meta marks the repository as failed; in this case only
+// the data collected during the build (like skipped files) is reported.
+func (r *repoBuildReport) setMeta(meta *RepositoryMeta) {
+	if meta == nil {
+		r.Status = "failed"
+		return
+	}
+	r.Files = len(meta.Files)
+	r.SLOC = meta.SLOC
+	r.RawBytes = meta.Size
+	r.MinifiedBytes = meta.MinifiedSize
+	r.SkippedFiles = r.SkippedFiles[:0]
+	for _, f := range meta.SkippedFiles {
+		r.addSkippedFile(f)
+	}
+	r.FilesByFlag = make(map[string]int)
+	for _, f := range meta.Files {
+		for _, flag := range fileFlagNames {
+			if filebits.Check(f.Flags, flag.mask) {
+				r.FilesByFlag[flag.name]++
+			}
+		}
+	}
+}
+
+func newBuildReport(shared *sharedContext, repos []*repoBuildReport, duration time.Duration) *buildReport {
+	report := &buildReport{
+		Version:      shared.meta.Version,
+		Duration:     durationMillis(duration),
+		Repositories: repos,
+	}
+	totals := &report.Totals
+	totals.FilesByFlag = make(map[string]int)
+	for _, r := range repos {
+		if r.Status == "failed" {
+			totals.FailedRepositories++
+			continue
+		}
+		totals.Repositories++
+		totals.Files += r.Files
+		totals.SkippedFiles += len(r.SkippedFiles)
+		totals.SLOC += r.SLOC
+		totals.RawBytes += r.RawBytes
+		totals.MinifiedBytes += r.MinifiedBytes
+		for name, n := range r.FilesByFlag {
+			totals.FilesByFlag[name] += n
+		}
+	}
+	totals.MaxFileDepth = shared.maxDepth
+	if shared.numFiles != 0 {
+		totals.AvgFileDepth = shared.totalDepth / shared.numFiles
+	}
+	totals.Warnings = shared.numWarnings
+	totals.Errors = shared.numErrors
+	return report
+}
+
+func (r *buildReport) WriteFile(filename string) error {
+	data, err := json.MarshalIndent(r, "", "\t")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	return os.WriteFile(filename, data, 0o666)
+}
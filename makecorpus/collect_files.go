@@ -1,44 +1,62 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"go/parser"
 	"go/token"
 	"io/fs"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/quasilyte/gocorpus/internal/goversion"
 )
 
 func collectFiles(ctx *context) *RepositoryMeta {
 	repo := ctx.repo
 	meta := &RepositoryMeta{
-		Name: repo.name,
-		Tags: repo.tags,
-		Git:  repo.git,
+		Name:         repo.name,
+		Tags:         repo.tags,
+		Source:       repo.sourceName(),
+		Git:          repo.git,
+		SourceModule: repo.module,
+		ConfigHash:   repo.configHash(),
 	}
 
-	// We're going to clone sources to a tmp dir.
-	// This dir will be removed when we're finished.
-	cloneTmpDir := filepath.Join(ctx.tmpDir, repo.name+"-tmp")
-	defer os.RemoveAll(cloneTmpDir)
-
-	ctx.logDebugf("doing a git clone")
-	out, err := exec.Command("git", "clone", "--depth=1", repo.git, cloneTmpDir).CombinedOutput()
+	fetchStart := time.Now()
+	sources, err := newSourceBackend(repo).fetch(ctx)
+	ctx.report.Timings.Fetch = durationMillis(time.Since(fetchStart))
 	if err != nil {
-		ctx.logErrorf("git clone: %v: %s", err, out)
+		ctx.logErrorf("fetch sources: %v", err)
 		return nil
 	}
-
-	out, err = exec.Command("git", "-C", cloneTmpDir, "rev-parse", "HEAD").CombinedOutput()
-	if err != nil {
-		ctx.logErrorf("git rev-parse: %v: %s", err, out)
-		return nil
+	defer sources.cleanup()
+	meta.Commit = sources.revision
+	cloneTmpDir := sources.dir
+
+	goMods := newGoModFinder(cloneTmpDir)
+	if rootGoMod, err := goMods.Find(cloneTmpDir); err != nil {
+		ctx.logWarnf("read go.mod: %v", err)
+	} else if rootGoMod != nil {
+		meta.GoVersion = rootGoMod.goVersion
 	}
-	meta.Commit = strings.TrimSpace(string(out))
 
 	ctx.logDebugf("processing files")
 
+	idents := newIdentIndex()
+	decls := newDeclIndex()
+	contentHash := sha256.New()
+
+	// Walk timing includes everything except the minification and archiving,
+	// these are measured separately and subtracted at the end.
+	walkStart := time.Now()
+	rules := newPathRules(repo)
+	moduleIndex := make(map[*goModFile]int)
+	var pkgNames []string
 	numFiles := 0
 	for _, srcRoot := range repo.srcRoots {
 		absSrcRoot := filepath.Join(cloneTmpDir, srcRoot)
@@ -46,13 +64,13 @@ func collectFiles(ctx *context) *RepositoryMeta {
 			if err != nil {
 				return err
 			}
+			rel, err := filepath.Rel(cloneTmpDir, path)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
 			if d.IsDir() {
-				skipDir := d.Name() == "node_modules" ||
-					strings.HasPrefix(d.Name(), "_") ||
-					strings.HasSuffix(path, "/testdata") ||
-					strings.HasSuffix(path, "/vendor") ||
-					strings.HasSuffix(path, "/third_party")
-				if skipDir {
+				if rules.skipDir(rel) {
 					return filepath.SkipDir
 				}
 				return nil
@@ -60,6 +78,9 @@ func collectFiles(ctx *context) *RepositoryMeta {
 			if !strings.HasSuffix(path, ".go") {
 				return nil
 			}
+			if rules.skipFile(rel) {
+				return nil
+			}
 
 			stat, err := d.Info()
 			if err != nil {
@@ -70,35 +91,76 @@ func collectFiles(ctx *context) *RepositoryMeta {
 			if err != nil {
 				return err
 			}
+
+			relPath := strings.TrimPrefix(path, absSrcRoot)
+			prettyPath := filepath.Join(repo.name, srcRoot, relPath)
+
+			// The walk order is lexical, so the hash is deterministic.
+			fmt.Fprintf(contentHash, "%s\x00%d\x00", filepath.ToSlash(prettyPath), len(rawSrc))
+			contentHash.Write(rawSrc)
+
 			fset := token.NewFileSet()
-			f, err := parser.ParseFile(fset, path, rawSrc, parser.ParseComments)
+			f, err := parser.ParseFile(fset, prettyPath, rawSrc, parser.ParseComments)
 			if err != nil {
-				return err
+				// A single unparsable file (e.g. a newer syntax or an intentionally
+				// broken test input) should not fail the whole repository.
+				ctx.logWarnf("skip file: %v", err)
+				skipped := SkippedFileMeta{
+					Name:  strings.TrimPrefix(prettyPath, repo.name+"/"),
+					Error: err.Error(),
+				}
+				meta.SkippedFiles = append(meta.SkippedFiles, skipped)
+				ctx.report.addSkippedFile(skipped)
+				return nil
 			}
 			sloc := fset.Position(f.End()).Line
 			meta.SLOC += sloc
+			minifyStart := time.Now()
 			minifiedSrc := minifyGo(fset, f)
-
-			relPath := strings.TrimPrefix(path, absSrcRoot)
-			prettyPath := filepath.Join(repo.name, srcRoot, relPath)
+			ctx.report.Timings.Minify += durationMillis(time.Since(minifyStart))
 
 			fileInfo := analyzeFile(d.Name(), f, rawSrc)
+			idents.AddFile(fileInfo.idents)
+			decls.AddFile(collectDecls(fset, f))
 			fileMeta := newFileMeta(fileInfo)
 			fileMeta.Name = strings.TrimPrefix(prettyPath, repo.name+"/")
 			fileMeta.SLOC = sloc
-			meta.Files = append(meta.Files, fileMeta)
-
-			ctx.totalDepth += int64(fileInfo.maxDepth)
-			if int64(fileInfo.maxDepth) > ctx.maxDepth {
-				ctx.maxDepth = int64(fileInfo.maxDepth)
+			fileMeta.Hash = hashFileContents(minifiedSrc)
+			goMod, err := goMods.Find(filepath.Dir(path))
+			if err != nil {
+				return err
+			}
+			if goMod != nil {
+				fileMeta.GoVersion = goversion.Max(goMod.goVersion, fileInfo.buildGoVersion)
+			} else {
+				fileMeta.GoVersion = fileInfo.buildGoVersion
 			}
+			if goMod != nil && goMod.module != "" {
+				i, ok := moduleIndex[goMod]
+				if !ok {
+					i = len(meta.Modules)
+					moduleIndex[goMod] = i
+					meta.Modules = append(meta.Modules, newModuleMeta(cloneTmpDir, goMod, sources.revision, repo))
+				}
+				fileMeta.Module = i
+				fileMeta.PkgPath = goMod.pkgPath(filepath.Dir(path))
+			}
+			meta.Files = append(meta.Files, fileMeta)
+			pkgNames = append(pkgNames, fileInfo.pkgName)
 
 			meta.Size += len(rawSrc)
 			meta.MinifiedSize += len(minifiedSrc)
 
+			archiveStart := time.Now()
 			if err := ctx.tar.AddFile(prettyPath, int64(stat.Mode()), minifiedSrc); err != nil {
 				return err
 			}
+			if ctx.commentsTar != nil {
+				if err := ctx.commentsTar.AddFile(prettyPath, int64(stat.Mode()), rawSrc); err != nil {
+					return err
+				}
+			}
+			ctx.report.Timings.Archive += durationMillis(time.Since(archiveStart))
 
 			numFiles++
 			return nil
@@ -109,8 +171,39 @@ func collectFiles(ctx *context) *RepositoryMeta {
 		}
 	}
 
-	ctx.numFiles += int64(numFiles)
+	meta.Packages = groupPackages(meta.Files, pkgNames)
+	ctx.report.Timings.Walk = durationMillis(time.Since(walkStart)) - ctx.report.Timings.Minify - ctx.report.Timings.Archive
+	if len(meta.SkippedFiles) != 0 {
+		atomic.AddInt64(&ctx.numSkippedFiles, int64(len(meta.SkippedFiles)))
+		ratio := float64(len(meta.SkippedFiles)) / float64(numFiles+len(meta.SkippedFiles))
+		if ratio > ctx.maxSkippedRatio {
+			ctx.logErrorf("too many skipped files: %d of %d", len(meta.SkippedFiles), numFiles+len(meta.SkippedFiles))
+			return nil
+		}
+	}
+	meta.ContentHash = hex.EncodeToString(contentHash.Sum(nil))
+	if repo.contentHash != "" && repo.contentHash != meta.ContentHash {
+		ctx.logErrorf("content hash mismatch: locked %s, got %s", repo.contentHash, meta.ContentHash)
+		return nil
+	}
+
+	indexStart := time.Now()
+	err = ctx.writeIndexFile(ctx.outputFiles()[1], idents)
+	if err == nil {
+		err = ctx.writeIndexFile(ctx.outputFiles()[2], decls)
+	}
+	ctx.report.Timings.Archive += durationMillis(time.Since(indexStart))
+	if err != nil {
+		ctx.logErrorf("write index: %v", err)
+		return nil
+	}
+
+	// Only the accepted repositories contribute to the totals.
+	for _, f := range meta.Files {
+		ctx.addFileDepth(f.MaxDepth)
+	}
+	atomic.AddInt64(&ctx.numFiles, int64(numFiles))
 
-	ctx.logDebugf("processed %d files (SLOC=%d)", numFiles, meta.SLOC)
+	ctx.logDebugf("processed %d files (SLOC=%d), skipped %d files", numFiles, meta.SLOC, len(meta.SkippedFiles))
 	return meta
 }
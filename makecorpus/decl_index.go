@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"io"
+	"strings"
+)
+
+// declInfo describes a single top-level declaration.
+//
+// The JSON field names are a part of the <repo>.decls.json format,
+// see app.ts declInfo for the consumer side.
+type declInfo struct {
+	// Kind is "func", "method", "type", "const" or "var".
+	Kind string
+
+	Name string
+
+	// Receiver is a method receiver type, like "*Client" or "List[T]".
+	// Only set for the "method" kind.
+	Receiver string `json:",omitempty"`
+
+	// TypeKind is "struct", "interface", "alias" or "other".
+	// Only set for the "type" kind.
+	TypeKind string `json:",omitempty"`
+
+	Exported bool
+
+	// Signature is a single-line declaration text without the
+	// function body, like "func (c *Client) Do(req *Request) error".
+	Signature string
+
+	// Line and Column are the name position inside the original
+	// (unminified) file.
+	Line   int
+	Column int
+}
+
+// declIndex collects the top-level declarations of the repository files.
+type declIndex struct {
+	files [][]declInfo
+}
+
+func newDeclIndex() *declIndex {
+	return &declIndex{}
+}
+
+// AddFile records the declarations of the next repository file.
+// Files should be added in the RepositoryMeta.Files order.
+func (idx *declIndex) AddFile(decls []declInfo) {
+	if decls == nil {
+		decls = []declInfo{}
+	}
+	idx.files = append(idx.files, decls)
+}
+
+// declIndexJSON is the index file format.
+//
+// Files are indexed in the same way as RepositoryMeta.Files.
+type declIndexJSON struct {
+	Files [][]declInfo
+}
+
+func (idx *declIndex) WriteJSON(w io.Writer) error {
+	data := declIndexJSON{Files: idx.files}
+	if data.Files == nil {
+		data.Files = [][]declInfo{}
+	}
+	return json.NewEncoder(w).Encode(data)
+}
+
+// collectDecls returns all named top-level declarations of f.
+// Blank (_) declarations are ignored.
+func collectDecls(fset *token.FileSet, f *ast.File) []declInfo {
+	var decls []declInfo
+	add := func(name *ast.Ident, d declInfo) {
+		if name.Name == "_" {
+			return
+		}
+		pos := fset.Position(name.Pos())
+		d.Name = name.Name
+		d.Exported = name.IsExported()
+		d.Line = pos.Line
+		d.Column = pos.Column
+		decls = append(decls, d)
+	}
+
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			var sig strings.Builder
+			sig.WriteString("func ")
+			d := declInfo{Kind: "func"}
+			if decl.Recv != nil && len(decl.Recv.List) != 0 {
+				recv := decl.Recv.List[0]
+				d.Kind = "method"
+				d.Receiver = types.ExprString(recv.Type)
+				sig.WriteString("(")
+				if len(recv.Names) != 0 {
+					sig.WriteString(recv.Names[0].Name + " ")
+				}
+				sig.WriteString(d.Receiver + ") ")
+			}
+			sig.WriteString(decl.Name.Name)
+			sig.WriteString(typeParamsString(decl.Type.TypeParams))
+			sig.WriteString(strings.TrimPrefix(types.ExprString(decl.Type), "func"))
+			d.Signature = sig.String()
+			add(decl.Name, d)
+
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					d := declInfo{Kind: "type", TypeKind: "other"}
+					switch spec.Type.(type) {
+					case *ast.StructType:
+						d.TypeKind = "struct"
+					case *ast.InterfaceType:
+						d.TypeKind = "interface"
+					}
+					sep := " "
+					if spec.Assign.IsValid() {
+						d.TypeKind = "alias"
+						sep = " = "
+					}
+					d.Signature = "type " + spec.Name.Name + typeParamsString(spec.TypeParams) + sep + types.ExprString(spec.Type)
+					add(spec.Name, d)
+
+				case *ast.ValueSpec:
+					kind := decl.Tok.String()
+					for i, name := range spec.Names {
+						sig := kind + " " + name.Name
+						if spec.Type != nil {
+							sig += " " + types.ExprString(spec.Type)
+						}
+						// Only the simple literal values are included,
+						// the complex initializers would bloat the index.
+						if i < len(spec.Values) {
+							if lit, ok := spec.Values[i].(*ast.BasicLit); ok {
+								sig += " = " + lit.Value
+							}
+						}
+						add(name, declInfo{Kind: kind, Signature: sig})
+					}
+				}
+			}
+		}
+	}
+
+	return decls
+}
+
+func typeParamsString(params *ast.FieldList) string {
+	if params == nil || len(params.List) == 0 {
+		return ""
+	}
+	parts := make([]string, len(params.List))
+	for i, field := range params.List {
+		names := make([]string, len(field.Names))
+		for j, name := range field.Names {
+			names[j] = name.Name
+		}
+		parts[i] = strings.Join(names, ", ") + " " + types.ExprString(field.Type)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
@@ -0,0 +1,51 @@
+package main
+
+// buildDependencyGraph returns the CorpusMeta.Dependencies edges.
+//
+// A repository depends on another one if any of its modules requires
+// a module that is provided by that other repository.
+// Several requirements of the same module are merged into a single edge
+// that is indirect only if all of these requirements are indirect.
+//
+// Like the duplicate files detection, the graph depends on the whole corpus,
+// so it's always recomputed, even for the reused repositories.
+func buildDependencyGraph(repos []*RepositoryMeta) []DependencyMeta {
+	// If several repositories provide the same module (e.g. a fork),
+	// the first one is used as a dependency target.
+	providers := make(map[string]string)
+	for _, repo := range repos {
+		for _, mod := range repo.Modules {
+			if mod.Path == "" {
+				continue
+			}
+			if _, ok := providers[mod.Path]; !ok {
+				providers[mod.Path] = repo.Name
+			}
+		}
+	}
+
+	var edges []DependencyMeta
+	for _, repo := range repos {
+		edgeIndex := make(map[string]int)
+		for _, mod := range repo.Modules {
+			for _, r := range mod.Requires {
+				to, ok := providers[r.Path]
+				if !ok || to == repo.Name {
+					continue
+				}
+				if i, ok := edgeIndex[r.Path]; ok {
+					edges[i].Indirect = edges[i].Indirect && r.Indirect
+					continue
+				}
+				edgeIndex[r.Path] = len(edges)
+				edges = append(edges, DependencyMeta{
+					From:     repo.Name,
+					To:       to,
+					Module:   r.Path,
+					Indirect: r.Indirect,
+				})
+			}
+		}
+	}
+	return edges
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/quasilyte/gocorpus/internal/filebits"
+)
+
+// hashFileContents returns a FileMeta.Hash for the given (minified) file contents.
+// 64 bits are enough to avoid the accidental collisions in a corpus of this size.
+func hashFileContents(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// markDuplicates sets the IsDuplicate flag for every file that has
+// the same hash as some other file that comes before it.
+// The first occurrence (in the repositories order) is a canonical one.
+//
+// Since the result depends on the whole corpus, the flags are always
+// recomputed, even for the repositories reused from the previous build.
+//
+// Returns the number of duplicates found.
+func markDuplicates(repos []*RepositoryMeta) int {
+	seen := make(map[string]struct{})
+	numDuplicates := 0
+	for _, repo := range repos {
+		for i := range repo.Files {
+			f := &repo.Files[i]
+			f.Flags &^= filebits.IsDuplicate
+			if f.Hash == "" {
+				continue
+			}
+			if _, ok := seen[f.Hash]; ok {
+				f.Flags |= filebits.IsDuplicate
+				numDuplicates++
+				continue
+			}
+			seen[f.Hash] = struct{}{}
+		}
+	}
+	return numDuplicates
+}
@@ -0,0 +1,731 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"github.com/quasilyte/gocorpus/internal/imports"
+)
+
+// featureScanner detects the language features usage.
+//
+// It works without the type information, so some of the features
+// are detected heuristically using the declarations from the same file.
+// Such features have two bits: the uses* bit is only set if the usage
+// can be proven and the mayUse* bit is only cleared if we can prove
+// that the feature is not used. The filters skip the files by these
+// bits before parsing them, so neither of them can be wrong in a way
+// that would drop a matching file.
+type featureScanner struct {
+	info *repositoryFileInfo
+
+	imports *imports.Table
+
+	// genericNames are the generic types and funcs declared in this file.
+	genericNames map[string]bool
+
+	// methodNames are the method names declared in this file.
+	methodNames map[string]bool
+
+	// typeMethods maps a receiver type name to its methods declared in this file.
+	typeMethods map[string]map[string]bool
+
+	// topLevelTypes are the package-level type declarations of this file.
+	topLevelTypes map[*ast.TypeSpec]bool
+
+	// fieldNames are the struct field names declared in this file.
+	fieldNames map[string]bool
+
+	// methodResults maps a method name declared in this file to its results.
+	// If there are several methods with the same name, all their results are recorded.
+	methodResults map[string][]*ast.FieldList
+
+	// nonFuncOperands are the expressions that are used in a context
+	// that rules out a func value, like a call expression Fun part
+	// or a selector expression X part.
+	nonFuncOperands map[ast.Expr]bool
+
+	// typeOperands are the expressions that are used in a context
+	// that requires a type, like a field type or a composite literal type.
+	typeOperands map[ast.Expr]bool
+}
+
+func newFeatureScanner(info *repositoryFileInfo, f *ast.File) *featureScanner {
+	s := &featureScanner{
+		info:            info,
+		imports:         imports.NewTable(f),
+		genericNames:    make(map[string]bool),
+		methodNames:     make(map[string]bool),
+		typeMethods:     make(map[string]map[string]bool),
+		topLevelTypes:   make(map[*ast.TypeSpec]bool),
+		fieldNames:      make(map[string]bool),
+		methodResults:   make(map[string][]*ast.FieldList),
+		nonFuncOperands: make(map[ast.Expr]bool),
+		typeOperands:    make(map[ast.Expr]bool),
+	}
+
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv != nil {
+				s.methodNames[decl.Name.Name] = true
+				s.methodResults[decl.Name.Name] = append(s.methodResults[decl.Name.Name], decl.Type.Results)
+				if len(decl.Recv.List) == 1 {
+					if recv := baseTypeName(decl.Recv.List[0].Type); recv != nil {
+						if s.typeMethods[recv.Name] == nil {
+							s.typeMethods[recv.Name] = make(map[string]bool)
+						}
+						s.typeMethods[recv.Name][decl.Name.Name] = true
+					}
+				}
+			}
+			if decl.Type.TypeParams != nil {
+				s.genericNames[decl.Name.Name] = true
+			}
+		case *ast.GenDecl:
+			if decl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				spec := spec.(*ast.TypeSpec)
+				s.topLevelTypes[spec] = true
+				if spec.TypeParams != nil {
+					s.genericNames[spec.Name.Name] = true
+				}
+			}
+		}
+	}
+	ast.Inspect(f, func(n ast.Node) bool {
+		if n, ok := n.(*ast.StructType); ok {
+			for _, field := range n.Fields.List {
+				for _, name := range field.Names {
+					s.fieldNames[name.Name] = true
+				}
+			}
+		}
+		return true
+	})
+
+	return s
+}
+
+func (s *featureScanner) Visit(n ast.Node) {
+	switch n := n.(type) {
+	case *ast.FuncDecl:
+		if n.Type.TypeParams != nil {
+			s.info.usesGenericDecls = true
+		}
+	case *ast.TypeSpec:
+		if n.TypeParams != nil {
+			s.info.usesGenericDecls = true
+		}
+		s.markTypeOperand(n.Type)
+	case *ast.Field:
+		s.markTypeOperand(n.Type)
+	case *ast.ValueSpec:
+		s.markTypeOperand(n.Type)
+	case *ast.CompositeLit:
+		s.markTypeOperand(n.Type)
+	case *ast.ArrayType:
+		s.markTypeOperand(n.Elt)
+	case *ast.MapType:
+		s.markTypeOperand(n.Key)
+		s.markTypeOperand(n.Value)
+	case *ast.ChanType:
+		s.markTypeOperand(n.Value)
+	case *ast.IndexListExpr:
+		s.info.usesGenericInst = true
+		s.info.mayUseGenericInst = true
+	case *ast.IndexExpr:
+		// Func values can't be map keys.
+		s.nonFuncOperands[unparen(n.X)] = true
+		s.nonFuncOperands[unparen(n.Index)] = true
+		if s.isGenericInst(n) {
+			s.info.usesGenericInst = true
+		}
+		if s.mayBeGenericInst(n) {
+			s.info.mayUseGenericInst = true
+		}
+	case *ast.GoStmt:
+		s.info.usesGoroutines = true
+	case *ast.SelectStmt:
+		s.info.usesSelect = true
+	case *ast.DeferStmt:
+		s.info.usesDefer = true
+	case *ast.BranchStmt:
+		switch n.Tok {
+		case token.GOTO:
+			s.info.usesGoto = true
+		case token.BREAK, token.CONTINUE:
+			if n.Label != nil {
+				s.info.usesLabeledBranch = true
+			}
+		}
+	case *ast.CallExpr:
+		s.nonFuncOperands[unparen(n.Fun)] = true
+	case *ast.SliceExpr:
+		s.nonFuncOperands[unparen(n.X)] = true
+	case *ast.StarExpr:
+		s.nonFuncOperands[unparen(n.X)] = true
+	case *ast.TypeAssertExpr:
+		s.nonFuncOperands[unparen(n.X)] = true
+	case *ast.UnaryExpr:
+		s.nonFuncOperands[unparen(n.X)] = true
+	case *ast.BinaryExpr:
+		// Func values can only be compared to nil.
+		if n.Op != token.EQL && n.Op != token.NEQ {
+			s.nonFuncOperands[unparen(n.X)] = true
+			s.nonFuncOperands[unparen(n.Y)] = true
+		}
+	case *ast.IncDecStmt:
+		s.nonFuncOperands[unparen(n.X)] = true
+	case *ast.AssignStmt:
+		// Method values are not assignable.
+		for _, lhs := range n.Lhs {
+			s.nonFuncOperands[unparen(lhs)] = true
+		}
+	case *ast.SelectorExpr:
+		s.nonFuncOperands[unparen(n.X)] = true
+		if s.isMethodValue(n) {
+			s.info.usesMethodValues = true
+		}
+		if s.mayBeMethodValue(n) {
+			s.info.mayUseMethodValues = true
+		}
+	case *ast.RangeStmt:
+		if s.isRangeFunc(n) {
+			s.info.usesRangeFunc = true
+		}
+		if s.mayBeRangeFunc(n) {
+			s.info.mayUseRangeFunc = true
+		}
+	case *ast.TypeSwitchStmt:
+		s.info.usesTypeSwitch = true
+	case *ast.StructType:
+		for _, field := range n.Fields.List {
+			if len(field.Names) == 0 {
+				s.info.usesEmbedding = true
+				break
+			}
+		}
+	}
+}
+
+// markTypeOperand records e as a type expression.
+func (s *featureScanner) markTypeOperand(e ast.Expr) {
+	switch e := unparen(e).(type) {
+	case nil:
+	case *ast.StarExpr:
+		s.markTypeOperand(e.X)
+	case *ast.Ellipsis:
+		s.markTypeOperand(e.Elt)
+	default:
+		s.typeOperands[e] = true
+	}
+}
+
+// isGenericInst reports whether the index expression is certainly a generic instantiation:
+// it's used as a type, the X is a generic declared in this file or the index is a type.
+func (s *featureScanner) isGenericInst(e *ast.IndexExpr) bool {
+	if s.typeOperands[e] {
+		return true
+	}
+	if ident, ok := unparen(e.X).(*ast.Ident); ok && ident.Obj != nil && s.genericNames[ident.Name] {
+		if ident.Obj.Kind == ast.Fun || ident.Obj.Kind == ast.Typ {
+			return true
+		}
+	}
+	return isType(e.Index)
+}
+
+// isType reports whether e is certainly a type expression.
+func isType(e ast.Expr) bool {
+	switch e := unparen(e).(type) {
+	case *ast.ArrayType, *ast.MapType, *ast.ChanType, *ast.FuncType, *ast.StructType, *ast.InterfaceType:
+		return true
+	case *ast.Ident:
+		if e.Obj != nil {
+			return e.Obj.Kind == ast.Typ
+		}
+		return predeclaredTypes[e.Name]
+	case *ast.StarExpr:
+		return isType(e.X)
+	case *ast.IndexExpr:
+		return isType(e.X)
+	case *ast.IndexListExpr:
+		return isType(e.X)
+	default:
+		return false
+	}
+}
+
+// mayBeGenericInst reports whether the index expression can be a generic instantiation.
+// We can't distinguish `f[T]` from `xs[i]` in general, so the expression
+// is only rejected if the X or the index is known to be a value.
+func (s *featureScanner) mayBeGenericInst(e *ast.IndexExpr) bool {
+	if ident, ok := unparen(e.X).(*ast.Ident); ok {
+		if s.genericNames[ident.Name] {
+			return true
+		}
+		if ident.Obj != nil && ident.Obj.Kind != ast.Fun && ident.Obj.Kind != ast.Typ {
+			return false
+		}
+	}
+	return s.mayBeType(e.Index)
+}
+
+// mayBeType reports whether e can be a type expression.
+func (s *featureScanner) mayBeType(e ast.Expr) bool {
+	switch e := unparen(e).(type) {
+	case *ast.ArrayType, *ast.MapType, *ast.ChanType, *ast.FuncType, *ast.StructType, *ast.InterfaceType:
+		return true
+	case *ast.Ident:
+		if e.Obj != nil {
+			return e.Obj.Kind == ast.Typ
+		}
+		// An unresolved identifier can be a type declared in another file.
+		return !predeclaredValues[e.Name]
+	case *ast.SelectorExpr:
+		// Only a package-qualified name can be a type.
+		pkg, ok := e.X.(*ast.Ident)
+		return ok && s.imports.PkgPath(pkg) != ""
+	case *ast.StarExpr:
+		return s.mayBeType(e.X)
+	case *ast.IndexExpr:
+		return s.mayBeType(e.X)
+	case *ast.IndexListExpr:
+		return s.mayBeType(e.X)
+	default:
+		return false
+	}
+}
+
+// isMethodValue reports whether the selector is certainly a method value, like `x.M` in `f(x.M)`:
+// the X is a variable of a type that has the M method declared in this file.
+func (s *featureScanner) isMethodValue(e *ast.SelectorExpr) bool {
+	if s.nonFuncOperands[e] {
+		return false
+	}
+	ident, ok := e.X.(*ast.Ident)
+	if !ok || ident.Obj == nil || ident.Obj.Kind != ast.Var {
+		return false
+	}
+	typ, value := varDecl(ident.Obj)
+	if typ == nil {
+		// x := T{} or x := &T{}
+		if addr, ok := unparen(value).(*ast.UnaryExpr); ok && addr.Op == token.AND {
+			value = addr.X
+		}
+		if lit, ok := unparen(value).(*ast.CompositeLit); ok {
+			typ = lit.Type
+		}
+	}
+	typeName := baseTypeName(typ)
+	if typeName == nil {
+		return false
+	}
+	// A local type can't have methods, but it can shadow a package-level one.
+	if typeName.Obj != nil {
+		if spec, ok := typeName.Obj.Decl.(*ast.TypeSpec); !ok || !s.topLevelTypes[spec] {
+			return false
+		}
+	}
+	return s.typeMethods[typeName.Name][e.Sel.Name]
+}
+
+// baseTypeName returns the T name of the T, *T or T[...] type expression.
+func baseTypeName(typ ast.Expr) *ast.Ident {
+	for {
+		switch e := unparen(typ).(type) {
+		case *ast.Ident:
+			return e
+		case *ast.StarExpr:
+			typ = e.X
+		case *ast.IndexExpr:
+			typ = e.X
+		case *ast.IndexListExpr:
+			typ = e.X
+		default:
+			return nil
+		}
+	}
+}
+
+// mayBeMethodValue reports whether the selector can be a method value.
+// The selector is rejected if it's used in a context where a func value
+// is not allowed, if it's known to be a field or if it's a qualified identifier.
+func (s *featureScanner) mayBeMethodValue(e *ast.SelectorExpr) bool {
+	if s.nonFuncOperands[e] {
+		return false
+	}
+	if s.fieldNames[e.Sel.Name] && !s.methodNames[e.Sel.Name] {
+		return false
+	}
+	if ident, ok := e.X.(*ast.Ident); ok {
+		if s.imports.PkgPath(ident) != "" {
+			return false
+		}
+		// T.M is a method expression.
+		if ident.Obj != nil && ident.Obj.Kind == ast.Typ {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *featureScanner) isRangeFunc(rng *ast.RangeStmt) bool {
+	return s.isFunc(rng.X, 0)
+}
+
+func (s *featureScanner) mayBeRangeFunc(rng *ast.RangeStmt) bool {
+	return s.mayBeFunc(rng.X, 0)
+}
+
+// isFunc reports whether e certainly has a func type.
+func (s *featureScanner) isFunc(e ast.Expr, depth int) bool {
+	if depth > maxResolveDepth {
+		return false
+	}
+	switch e := unparen(e).(type) {
+	case *ast.FuncLit:
+		return true
+	case *ast.Ident:
+		if e.Obj == nil {
+			return false
+		}
+		switch e.Obj.Kind {
+		case ast.Fun:
+			return true
+		case ast.Var:
+			typ, value := varDecl(e.Obj)
+			if typ != nil {
+				return s.isFuncType(typ, depth+1)
+			}
+			return value != nil && s.isFunc(value, depth+1)
+		}
+		return false
+	case *ast.CallExpr:
+		switch fn := unparen(e.Fun).(type) {
+		case *ast.Ident:
+			if fn.Obj != nil && fn.Obj.Kind == ast.Fun {
+				if decl, ok := fn.Obj.Decl.(*ast.FuncDecl); ok {
+					return s.resultsIsFunc(decl.Type.Results, depth+1)
+				}
+			}
+		case *ast.SelectorExpr:
+			if pkg, ok := fn.X.(*ast.Ident); ok {
+				return stdlibIterFuncs[s.imports.PkgPath(pkg)+"."+fn.Sel.Name]
+			}
+		case *ast.FuncLit:
+			return s.resultsIsFunc(fn.Type.Results, depth+1)
+		}
+		return false
+	case *ast.TypeAssertExpr:
+		return e.Type != nil && s.isFuncType(e.Type, depth+1)
+	default:
+		return false
+	}
+}
+
+func (s *featureScanner) resultsIsFunc(results *ast.FieldList, depth int) bool {
+	return results != nil && results.NumFields() == 1 && s.isFuncType(results.List[0].Type, depth)
+}
+
+// isFuncType reports whether typ is certainly a func type, like
+// iter.Seq or a type declared as a func in this file.
+func (s *featureScanner) isFuncType(typ ast.Expr, depth int) bool {
+	if depth > maxResolveDepth {
+		return false
+	}
+	switch typ := unparen(typ).(type) {
+	case *ast.FuncType:
+		return true
+	case *ast.Ident:
+		if typ.Obj == nil {
+			return false
+		}
+		spec, ok := typ.Obj.Decl.(*ast.TypeSpec)
+		return ok && s.isFuncType(spec.Type, depth+1)
+	case *ast.IndexExpr:
+		return s.isFuncType(typ.X, depth)
+	case *ast.IndexListExpr:
+		return s.isFuncType(typ.X, depth)
+	case *ast.SelectorExpr:
+		pkg, ok := typ.X.(*ast.Ident)
+		return ok && s.imports.PkgPath(pkg) == "iter" && (typ.Sel.Name == "Seq" || typ.Sel.Name == "Seq2")
+	default:
+		return false
+	}
+}
+
+// varDecl returns the declared type or the initializer of the variable.
+// Both are nil if the variable is declared in some other way,
+// like a multi-value assignment.
+func varDecl(obj *ast.Object) (typ, value ast.Expr) {
+	switch decl := obj.Decl.(type) {
+	case *ast.Field:
+		return decl.Type, nil
+	case *ast.ValueSpec:
+		if decl.Type != nil {
+			return decl.Type, nil
+		}
+		if len(decl.Names) == len(decl.Values) {
+			for i, name := range decl.Names {
+				if name.Obj == obj {
+					return nil, decl.Values[i]
+				}
+			}
+		}
+	case *ast.AssignStmt:
+		if len(decl.Lhs) == len(decl.Rhs) {
+			for i, lhs := range decl.Lhs {
+				if lhs, ok := lhs.(*ast.Ident); ok && lhs.Obj == obj {
+					return nil, decl.Rhs[i]
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+// maxResolveDepth limits the declarations chain that mayBeFunc follows.
+const maxResolveDepth = 8
+
+// mayBeFunc reports whether e can have a func type.
+// Only the expressions of a known non-func type are rejected.
+func (s *featureScanner) mayBeFunc(e ast.Expr, depth int) bool {
+	if depth > maxResolveDepth {
+		return true
+	}
+	switch e := unparen(e).(type) {
+	case *ast.FuncLit:
+		return true
+	case *ast.BasicLit, *ast.CompositeLit, *ast.SliceExpr, *ast.BinaryExpr:
+		return false
+	case *ast.UnaryExpr:
+		// A value received from a channel can be a func.
+		// The parser also records the range clause variables
+		// as declared by a `range x` unary expression.
+		return e.Op == token.ARROW || e.Op == token.RANGE
+	case *ast.Ident:
+		return s.identMayBeFunc(e, depth)
+	case *ast.CallExpr:
+		return s.callMayReturnFunc(e, depth)
+	case *ast.TypeAssertExpr:
+		return e.Type == nil || s.typeMayBeFunc(e.Type, depth)
+	default:
+		return true
+	}
+}
+
+func (s *featureScanner) identMayBeFunc(e *ast.Ident, depth int) bool {
+	if e.Obj == nil {
+		// An unresolved identifier can be declared in another file.
+		return !predeclaredValues[e.Name]
+	}
+	switch e.Obj.Kind {
+	case ast.Fun:
+		return true
+	case ast.Var:
+		typ, value := varDecl(e.Obj)
+		if typ != nil {
+			return s.typeMayBeFunc(typ, depth+1)
+		}
+		if value != nil {
+			return s.mayBeFunc(value, depth+1)
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *featureScanner) callMayReturnFunc(call *ast.CallExpr, depth int) bool {
+	switch fn := unparen(call.Fun).(type) {
+	case *ast.Ident:
+		if fn.Obj == nil {
+			if builtinFuncs[fn.Name] {
+				return false
+			}
+			if predeclaredTypes[fn.Name] {
+				return false // A conversion
+			}
+			return true
+		}
+		switch fn.Obj.Kind {
+		case ast.Typ:
+			return s.typeMayBeFunc(fn, depth+1) // A conversion
+		case ast.Fun:
+			if decl, ok := fn.Obj.Decl.(*ast.FuncDecl); ok {
+				return s.resultsMayBeFunc(decl.Type.Results, depth+1)
+			}
+		}
+		return true
+	case *ast.SelectorExpr:
+		if pkg, ok := fn.X.(*ast.Ident); ok {
+			if pkgPath := s.imports.PkgPath(pkg); pkgPath != "" {
+				// The stdlib is the only package we know the API of.
+				if isStdlibPath(pkgPath) {
+					return stdlibIterFuncs[pkgPath+"."+fn.Sel.Name]
+				}
+				return true
+			}
+		}
+		results, ok := s.methodResults[fn.Sel.Name]
+		if !ok {
+			return true
+		}
+		for _, r := range results {
+			if s.resultsMayBeFunc(r, depth+1) {
+				return true
+			}
+		}
+		return false
+	case *ast.FuncLit:
+		return s.resultsMayBeFunc(fn.Type.Results, depth+1)
+	case *ast.ArrayType, *ast.MapType, *ast.ChanType, *ast.StructType, *ast.InterfaceType:
+		return false // A conversion
+	default:
+		return true
+	}
+}
+
+// resultsMayBeFunc reports whether a func with these results
+// can be used as a single func-typed value.
+func (s *featureScanner) resultsMayBeFunc(results *ast.FieldList, depth int) bool {
+	if results == nil || results.NumFields() != 1 {
+		return false
+	}
+	return s.typeMayBeFunc(results.List[0].Type, depth)
+}
+
+// typeMayBeFunc reports whether typ can be a func type, like
+// iter.Seq, a type declared as a func or a type parameter.
+func (s *featureScanner) typeMayBeFunc(typ ast.Expr, depth int) bool {
+	if depth > maxResolveDepth {
+		return true
+	}
+	switch typ := unparen(typ).(type) {
+	case *ast.FuncType:
+		return true
+	case *ast.ArrayType, *ast.MapType, *ast.ChanType, *ast.StructType, *ast.InterfaceType, *ast.StarExpr, *ast.Ellipsis:
+		return false
+	case *ast.Ident:
+		if typ.Obj == nil {
+			// A type declared in another file.
+			return !predeclaredTypes[typ.Name]
+		}
+		if spec, ok := typ.Obj.Decl.(*ast.TypeSpec); ok {
+			return s.typeMayBeFunc(spec.Type, depth+1)
+		}
+		return true // A type parameter
+	case *ast.IndexExpr:
+		return s.typeMayBeFunc(typ.X, depth)
+	case *ast.IndexListExpr:
+		return s.typeMayBeFunc(typ.X, depth)
+	case *ast.SelectorExpr:
+		pkg, ok := typ.X.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		pkgPath := s.imports.PkgPath(pkg)
+		if pkgPath == "iter" {
+			return typ.Sel.Name == "Seq" || typ.Sel.Name == "Seq2"
+		}
+		return true
+	default:
+		return true
+	}
+}
+
+// isStdlibPath reports whether the import path belongs to the standard library.
+// Only the stdlib paths have no dot in their first element.
+func isStdlibPath(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
+
+// stdlibIterFuncs are the stdlib package-level funcs that return iterators (as of Go 1.24).
+var stdlibIterFuncs = map[string]bool{
+	"maps.All":              true,
+	"maps.Keys":             true,
+	"maps.Values":           true,
+	"slices.All":            true,
+	"slices.Backward":       true,
+	"slices.Chunk":          true,
+	"slices.Values":         true,
+	"strings.Lines":         true,
+	"strings.SplitSeq":      true,
+	"strings.SplitAfterSeq": true,
+	"strings.FieldsSeq":     true,
+	"strings.FieldsFuncSeq": true,
+	"bytes.Lines":           true,
+	"bytes.SplitSeq":        true,
+	"bytes.SplitAfterSeq":   true,
+	"bytes.FieldsSeq":       true,
+	"bytes.FieldsFuncSeq":   true,
+}
+
+func unparen(e ast.Expr) ast.Expr {
+	for {
+		p, ok := e.(*ast.ParenExpr)
+		if !ok {
+			return e
+		}
+		e = p.X
+	}
+}
+
+var predeclaredValues = map[string]bool{
+	"true":  true,
+	"false": true,
+	"nil":   true,
+	"iota":  true,
+}
+
+var builtinFuncs = map[string]bool{
+	"append":  true,
+	"cap":     true,
+	"clear":   true,
+	"close":   true,
+	"complex": true,
+	"copy":    true,
+	"delete":  true,
+	"imag":    true,
+	"len":     true,
+	"make":    true,
+	"max":     true,
+	"min":     true,
+	"new":     true,
+	"panic":   true,
+	"print":   true,
+	"println": true,
+	"real":    true,
+	"recover": true,
+}
+
+var predeclaredTypes = map[string]bool{
+	"any":        true,
+	"bool":       true,
+	"byte":       true,
+	"comparable": true,
+	"complex64":  true,
+	"complex128": true,
+	"error":      true,
+	"float32":    true,
+	"float64":    true,
+	"int":        true,
+	"int8":       true,
+	"int16":      true,
+	"int32":      true,
+	"int64":      true,
+	"rune":       true,
+	"string":     true,
+	"uint":       true,
+	"uint8":      true,
+	"uint16":     true,
+	"uint32":     true,
+	"uint64":     true,
+	"uintptr":    true,
+}
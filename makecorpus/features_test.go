@@ -0,0 +1,85 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestFeatureScanner(t *testing.T) {
+	type features struct {
+		genericInst  bool
+		methodValues bool
+		rangeFunc    bool
+	}
+	tests := []struct {
+		src    string
+		uses   features
+		mayUse features
+	}{
+		// Generic instantiations.
+		{`var p atomic.Pointer[Conn]`, features{genericInst: true}, features{genericInst: true}},
+		{`type T struct{ p *atomic.Pointer[Conn] }`, features{genericInst: true}, features{genericInst: true}},
+		{`func f() { _ = pkg.List[Conn]{} }`, features{genericInst: true}, features{genericInst: true}},
+		{`func f() { _ = slices.Index[[]int] }`, features{genericInst: true}, features{genericInst: true}},
+		{`func f[T any]() { g[T]() }`, features{genericInst: true}, features{genericInst: true}},
+		{`func g[T any]() {}; func f() { g[MyInt]() }`, features{genericInst: true}, features{genericInst: true}},
+		{`func f() { _ = pkg.Func[MyInt] }`, features{}, features{genericInst: true}},
+		{`func f(xs []int, i int) int { return xs[i] }`, features{}, features{}},
+		{`func f(m map[string]int) int { return m["k"] }`, features{}, features{}},
+		{`func f(xs []int, s *S) int { return xs[s.i] }`, features{}, features{}},
+
+		// Method values.
+		{`type S struct{}; func (s *S) handle() {}; func f(s *S) { http.HandleFunc("/", s.handle) }`, features{methodValues: true}, features{methodValues: true}},
+		{`type S struct{}; func (S) run() {}; func f() { s := &S{}; g := s.run; g() }`, features{methodValues: true}, features{methodValues: true}},
+		{`func f(s *S) { http.HandleFunc("/", s.handle) }`, features{}, features{methodValues: true}},
+		{`func f(s *S) { g := s.run; g() }`, features{}, features{methodValues: true}},
+		{`type S struct{}; func (S) run() {}; func f() { type S struct{ run func() }; var s S; g := s.run; g() }`, features{}, features{methodValues: true}},
+		{`type S struct{ x int }; func f(s *S) int { return s.x }`, features{}, features{}},
+		{`type S struct{}; func (S) M() {}; func f(s S) { s.M() }`, features{}, features{}},
+		{`func f(s *S) { s.handle(); s.x++; _ = s.y + 1 }`, features{}, features{}},
+		{`func f() { _ = strings.ToUpper }`, features{}, features{}},
+		{`type S struct{}; func (S) M() {}; func f() { _ = S.M }`, features{}, features{}},
+
+		// Range-over-func.
+		{`func f(seq func(func(int) bool)) { for range seq {} }`, features{rangeFunc: true}, features{rangeFunc: true}},
+		{`func f(seq iter.Seq[int]) { for range seq {} }`, features{genericInst: true, rangeFunc: true}, features{genericInst: true, rangeFunc: true}},
+		{`func f(xs []int) { for range slices.Values(xs) {} }`, features{rangeFunc: true}, features{rangeFunc: true}},
+		{`func f(s string) { for range strings.SplitSeq(s, ",") {} }`, features{rangeFunc: true}, features{rangeFunc: true}},
+		{`type Seq func(func() bool); func all() Seq { return nil }; func f() { for range all() {} }`, features{rangeFunc: true}, features{rangeFunc: true}},
+		{`func f() { for range all() {} }`, features{}, features{rangeFunc: true}},
+		{`func f(fns []func(func() bool)) { for _, fn := range fns { for range fn {} } }`, features{}, features{rangeFunc: true}},
+		{`func f(s *S) { for range s.All() {} }`, features{}, features{rangeFunc: true}},
+		{`func f(xs []int) { for range xs {} }`, features{}, features{}},
+		{`func f(s string) { for range strings.Split(s, ",") {} }`, features{}, features{}},
+		{`func f() { xs := make([]int, 10); for range xs {} }`, features{}, features{}},
+		{`func f() { for range 10 {} }`, features{}, features{}},
+		{`func f() []int { return nil }; func g() { for range f() {} }`, features{}, features{}},
+		{`type List []int; func f(l List) { for range l {} }`, features{}, features{}},
+	}
+
+	for _, test := range tests {
+		src := "package p\nimport (\"iter\"; \"net/http\"; \"slices\"; \"strings\"; \"sync/atomic\")\n" + test.src
+		f, err := parser.ParseFile(token.NewFileSet(), "test.go", src, 0)
+		if err != nil {
+			t.Fatalf("parse %q: %v", test.src, err)
+		}
+		info := analyzeFile("test.go", f, []byte(src))
+		uses := features{
+			genericInst:  info.usesGenericInst,
+			methodValues: info.usesMethodValues,
+			rangeFunc:    info.usesRangeFunc,
+		}
+		if uses != test.uses {
+			t.Errorf("%s:\nhave uses %+v\nwant uses %+v", test.src, uses, test.uses)
+		}
+		mayUse := features{
+			genericInst:  info.mayUseGenericInst,
+			methodValues: info.mayUseMethodValues,
+			rangeFunc:    info.mayUseRangeFunc,
+		}
+		if mayUse != test.mayUse {
+			t.Errorf("%s:\nhave mayUse %+v\nwant mayUse %+v", test.src, mayUse, test.mayUse)
+		}
+	}
+}
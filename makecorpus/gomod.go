@@ -0,0 +1,225 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/quasilyte/gocorpus/internal/goversion"
+)
+
+// goModFile is a go.mod file contents subset that is used by the makecorpus.
+type goModFile struct {
+	// dir is a directory that contains this go.mod file.
+	dir string
+
+	module    string
+	goVersion string
+	toolchain string
+
+	requires []goModRequire
+	replaces []goModReplace
+}
+
+type goModRequire struct {
+	path     string
+	version  string
+	indirect bool
+}
+
+// goModReplace is a replace directive.
+// The old version is optional, the new version is
+// empty if the replacement is a local directory.
+type goModReplace struct {
+	oldPath    string
+	oldVersion string
+	newPath    string
+	newVersion string
+}
+
+// parseGoMod parses the go.mod file data.
+//
+// We don't need the full golang.org/x/mod/modfile machinery here,
+// so this parser is very forgiving: it ignores everything it doesn't understand.
+func parseGoMod(data []byte) *goModFile {
+	f := &goModFile{}
+	block := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		comment := ""
+		if i := strings.Index(line, "//"); i != -1 {
+			comment = strings.TrimSpace(line[i+len("//"):])
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if block != "" {
+			if fields[0] == ")" {
+				block = ""
+				continue
+			}
+			f.addDirective(block, fields, comment)
+			continue
+		}
+		if len(fields) == 2 && fields[1] == "(" {
+			block = fields[0]
+			continue
+		}
+		f.addDirective(fields[0], fields[1:], comment)
+	}
+	return f
+}
+
+func (f *goModFile) addDirective(verb string, args []string, comment string) {
+	if len(args) == 0 {
+		return
+	}
+	switch verb {
+	case "module":
+		f.module = unquoteGoModString(args[0])
+	case "go":
+		f.goVersion = goversion.Lang(args[0])
+	case "toolchain":
+		f.toolchain = args[0]
+	case "require":
+		if len(args) != 2 {
+			return
+		}
+		f.requires = append(f.requires, goModRequire{
+			path:    unquoteGoModString(args[0]),
+			version: unquoteGoModString(args[1]),
+			// The "// indirect" comment can be followed by other comments.
+			indirect: comment == "indirect" || strings.HasPrefix(comment, "indirect;"),
+		})
+	case "replace":
+		// old [version] => new [version]
+		arrow := -1
+		for i, arg := range args {
+			if arg == "=>" {
+				arrow = i
+				break
+			}
+		}
+		if arrow < 1 || arrow > 2 || len(args)-arrow < 2 || len(args)-arrow > 3 {
+			return
+		}
+		r := goModReplace{
+			oldPath: unquoteGoModString(args[0]),
+			newPath: unquoteGoModString(args[arrow+1]),
+		}
+		if arrow == 2 {
+			r.oldVersion = unquoteGoModString(args[1])
+		}
+		if len(args)-arrow == 3 {
+			r.newVersion = unquoteGoModString(args[arrow+2])
+		}
+		f.replaces = append(f.replaces, r)
+	}
+}
+
+// joinModuleVersion returns a "path@version" string
+// or just a path if the version is empty.
+func joinModuleVersion(path, version string) string {
+	if version == "" {
+		return path
+	}
+	return path + "@" + version
+}
+
+func unquoteGoModString(s string) string {
+	if strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "`") {
+		if v, err := strconv.Unquote(s); err == nil {
+			return v
+		}
+	}
+	return s
+}
+
+// pkgPath returns an import path of the package inside the dir.
+// The dir should be governed by this go.mod file.
+func (f *goModFile) pkgPath(dir string) string {
+	rel, err := filepath.Rel(f.dir, dir)
+	if err != nil || rel == "." {
+		return f.module
+	}
+	// The GOROOT/src module is "std", but its packages
+	// are imported without the module prefix, like "net/http".
+	if f.module == "std" {
+		return filepath.ToSlash(rel)
+	}
+	return f.module + "/" + filepath.ToSlash(rel)
+}
+
+func newModuleMeta(root string, f *goModFile, revision string, repo *repository) ModuleMeta {
+	m := ModuleMeta{
+		Path:      f.module,
+		GoVersion: f.goVersion,
+		Toolchain: f.toolchain,
+	}
+	for _, r := range f.requires {
+		m.Requires = append(m.Requires, ModuleRequireMeta{
+			Path:     r.path,
+			Version:  r.version,
+			Indirect: r.indirect,
+		})
+	}
+	for _, r := range f.replaces {
+		m.Replaces = append(m.Replaces, ModuleReplaceMeta{
+			Old: joinModuleVersion(r.oldPath, r.oldVersion),
+			New: joinModuleVersion(r.newPath, r.newVersion),
+		})
+	}
+	if rel, err := filepath.Rel(root, f.dir); err == nil {
+		m.Dir = filepath.ToSlash(rel)
+	}
+	// A module zip contains exactly one module, so its version is known.
+	if modPath, _, _ := strings.Cut(repo.module, "@"); modPath != "" && modPath == f.module {
+		m.Version = revision
+	}
+	return m
+}
+
+// goModFinder locates the go.mod file that governs a directory.
+// The search doesn't go above the root directory.
+type goModFinder struct {
+	root  string
+	cache map[string]*goModFile
+}
+
+func newGoModFinder(root string) *goModFinder {
+	return &goModFinder{
+		root:  filepath.Clean(root),
+		cache: make(map[string]*goModFile),
+	}
+}
+
+// Find returns the go.mod file that governs the dir.
+// A nil result means that there is no go.mod in dir or in its parents.
+func (finder *goModFinder) Find(dir string) (*goModFile, error) {
+	dir = filepath.Clean(dir)
+	if f, ok := finder.cache[dir]; ok {
+		return f, nil
+	}
+
+	var f *goModFile
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	switch {
+	case err == nil:
+		f = parseGoMod(data)
+		f.dir = dir
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, err
+	case dir != finder.root && strings.HasPrefix(dir, finder.root):
+		f, err = finder.Find(filepath.Dir(dir))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	finder.cache[dir] = f
+	return f, nil
+}
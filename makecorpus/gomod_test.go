@@ -0,0 +1,30 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestGoModPkgPath(t *testing.T) {
+	root := filepath.FromSlash("/repo")
+	tests := []struct {
+		module string
+		dir    string
+		want   string
+	}{
+		{"example.com/m", "", "example.com/m"},
+		{"example.com/m", "pkg/a", "example.com/m/pkg/a"},
+		{"std", "net/http", "net/http"},
+		{"std", "vendor/golang.org/x/net/http2/hpack", "vendor/golang.org/x/net/http2/hpack"},
+		{"cmd", "go/internal/work", "cmd/go/internal/work"},
+	}
+
+	for _, test := range tests {
+		f := parseGoMod([]byte("module " + test.module + "\n\ngo 1.21\n"))
+		f.dir = root
+		have := f.pkgPath(filepath.Join(root, filepath.FromSlash(test.dir)))
+		if have != test.want {
+			t.Errorf("module %s, dir %q:\nhave %q\nwant %q", test.module, test.dir, have, test.want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// identIndex maps the identifier names to the repository files that use them.
+//
+// The identifiers that are used in most of the files are not indexed:
+// they're listed in the Common section instead, since they
+// can't help to reduce the number of the scanned files anyway.
+type identIndex struct {
+	numFiles int
+	postings map[string][]int
+}
+
+func newIdentIndex() *identIndex {
+	return &identIndex{postings: make(map[string][]int)}
+}
+
+// AddFile records the identifiers of the next repository file.
+// Files should be added in the RepositoryMeta.Files order.
+func (idx *identIndex) AddFile(idents map[string]struct{}) {
+	fileIndex := idx.numFiles
+	idx.numFiles++
+	for name := range idents {
+		idx.postings[name] = append(idx.postings[name], fileIndex)
+	}
+}
+
+// identIndexJSON is the index file format.
+//
+// The Postings file index lists are delta-encoded:
+// every element is a difference from the previous one.
+type identIndexJSON struct {
+	NumFiles int
+	Common   []string
+	Postings map[string][]int
+}
+
+func (idx *identIndex) WriteJSON(w io.Writer) error {
+	data := identIndexJSON{
+		NumFiles: idx.numFiles,
+		Common:   []string{},
+		Postings: make(map[string][]int, len(idx.postings)),
+	}
+	for name, files := range idx.postings {
+		if len(files)*2 > idx.numFiles {
+			data.Common = append(data.Common, name)
+			continue
+		}
+		deltas := make([]int, len(files))
+		prev := 0
+		for i, fileIndex := range files {
+			deltas[i] = fileIndex - prev
+			prev = fileIndex
+		}
+		data.Postings[name] = deltas
+	}
+	sort.Strings(data.Common)
+	return json.NewEncoder(w).Encode(data)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// loadPrevCorpus reads the corpus.json that was produced by the previous run.
+// It returns nil if there is nothing that can be reused.
+func loadPrevCorpus(ctx *sharedContext) (map[string]*RepositoryMeta, error) {
+	data, err := os.ReadFile(filepath.Join(ctx.outDir, "corpus.json"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var prev CorpusMeta
+	if err := json.Unmarshal(data, &prev); err != nil {
+		return nil, err
+	}
+	if prev.Version != ctx.meta.Version {
+		return nil, fmt.Errorf("corpus version mismatch: have %d, want %d", prev.Version, ctx.meta.Version)
+	}
+	if prev.WithComments != ctx.meta.WithComments {
+		return nil, errors.New("comments archives setting mismatch")
+	}
+	m := make(map[string]*RepositoryMeta, len(prev.Repositories))
+	for _, repo := range prev.Repositories {
+		m[repo.Name] = repo
+	}
+	return m, nil
+}
+
+// reusePrevMeta returns the previous run repository metadata
+// if the repository sources and its collection settings didn't change since then.
+func reusePrevMeta(ctx *context, prev map[string]*RepositoryMeta) *RepositoryMeta {
+	repo := ctx.repo
+	meta := prev[repo.name]
+	if meta == nil {
+		return nil
+	}
+	if meta.Source != repo.sourceName() || meta.Git != repo.git || meta.SourceModule != repo.module {
+		ctx.logDebugf("sources location changed, can't reuse the previous build")
+		return nil
+	}
+	if meta.ConfigHash != repo.configHash() {
+		ctx.logDebugf("src roots or path rules changed, can't reuse the previous build")
+		return nil
+	}
+	if repo.contentHash != "" && meta.ContentHash != repo.contentHash {
+		ctx.logDebugf("content hash differs from the locked one, can't reuse the previous build")
+		return nil
+	}
+	for _, filename := range ctx.outputFiles() {
+		if _, err := os.Stat(filename); err != nil {
+			ctx.logDebugf("can't reuse the previous build: %v", err)
+			return nil
+		}
+	}
+	commit, err := newSourceBackend(repo).resolveRevision(ctx)
+	if err != nil {
+		ctx.logWarnf("resolve sources revision: %v", err)
+		return nil
+	}
+	if commit == "" {
+		ctx.logDebugf("sources revision is unknown, can't reuse the previous build")
+		return nil
+	}
+	if commit != meta.Commit {
+		ctx.logDebugf("commit changed: %s -> %s", meta.Commit, commit)
+		return nil
+	}
+	// Tags are not a part of the sources, so they can be updated for free.
+	meta.Tags = repo.tags
+	return meta
+}
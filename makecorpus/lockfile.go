@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// corpusLock describes the exact sources a corpus was built from.
+// It's written next to the corpus.json as corpus.lock.json and
+// can be passed back to makecorpus with -lock to rebuild the same corpus.
+type corpusLock struct {
+	Version      int              `json:"version"`
+	Repositories []repositoryLock `json:"repositories"`
+}
+
+type repositoryLock struct {
+	Name        string `json:"name"`
+	Source      string `json:"source"`
+	Git         string `json:"git,omitempty"`
+	Module      string `json:"module,omitempty"`
+	Commit      string `json:"commit"`
+	ContentHash string `json:"content_hash"`
+}
+
+func newCorpusLock(meta *CorpusMeta) *corpusLock {
+	lock := &corpusLock{Version: meta.Version}
+	for _, repo := range meta.Repositories {
+		lock.Repositories = append(lock.Repositories, repositoryLock{
+			Name:        repo.Name,
+			Source:      repo.Source,
+			Git:         repo.Git,
+			Module:      repo.SourceModule,
+			Commit:      repo.Commit,
+			ContentHash: repo.ContentHash,
+		})
+	}
+	return lock
+}
+
+func loadCorpusLock(filename string) (*corpusLock, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var lock corpusLock
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&lock); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return &lock, nil
+}
+
+func (lock *corpusLock) WriteFile(filename string) error {
+	data, err := json.MarshalIndent(lock, "", "\t")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	return os.WriteFile(filename, data, 0o666)
+}
+
+// pinned returns a repositories set that contains only the locked repositories.
+// Every repository is pinned to its locked sources revision and content hash.
+// The other repository properties (tags, source roots) come from the original set.
+//
+// The "dir" sources can't be pinned, only their content hash is checked.
+func (set *repoSet) pinned(lock *corpusLock) (*repoSet, error) {
+	byName := make(map[string]*repository, len(set.repos))
+	for _, repo := range set.repos {
+		byName[repo.name] = repo
+	}
+	result := &repoSet{tags: set.tags}
+	for _, l := range lock.Repositories {
+		repo, ok := byName[l.Name]
+		if !ok {
+			return nil, fmt.Errorf("%s: locked repo is not in the repositories list", l.Name)
+		}
+		if l.Source != repo.sourceName() {
+			return nil, fmt.Errorf("%s: locked source is %q, but repositories list has %q", l.Name, l.Source, repo.sourceName())
+		}
+		pinned := *repo
+		pinned.contentHash = l.ContentHash
+		switch l.Source {
+		case "git":
+			if l.Commit == "" {
+				return nil, fmt.Errorf("%s: empty locked commit", l.Name)
+			}
+			pinned.git = l.Git
+			pinned.ref = l.Commit
+		case "modzip":
+			pinned.module = l.Module
+		}
+		result.repos = append(result.repos, &pinned)
+	}
+	return result, nil
+}
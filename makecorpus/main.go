@@ -2,114 +2,337 @@ package main
 
 import (
 	"bytes"
+	"compress/gzip"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 func main() {
 	log.SetFlags(0)
+	startTime := time.Now()
 
-	ctx := &context{
+	shared := &sharedContext{
 		tmpDir: os.TempDir(),
 		meta: &CorpusMeta{
 			Version: corpusVersion,
 		},
 	}
 
-	flag.BoolVar(&ctx.verbose, "v", false, "whether to print debug output")
+	flag.BoolVar(&shared.verbose, "v", false, "whether to print debug output")
 	outputDir := flag.String("o", "corpus-output", "the output directory")
-	noCompression := flag.Bool("no-gzip", false, "if provided, raw tars will be produced, without gz compression")
+	noCompression := flag.Bool("no-gzip", false, "if provided, raw tars and indexes will be produced, without gz compression")
+	withComments := flag.Bool("comments", false, "if provided, also produce the <repo>.comments archives with unminified sources")
+	reposFile := flag.String("repos", "", "a JSON file with repositories list to use instead of the built-in one")
+	lockFile := flag.String("lock", "", "a corpus.lock.json file to rebuild the corpus from")
+	incremental := flag.Bool("incremental", false, "if provided, repositories that didn't change since the previous run are not rebuilt")
+	flag.StringVar(&shared.modProxy, "modproxy", defaultModProxy(), "a GOPROXY-like directory or URL to fetch the module zips from")
+	flag.Float64Var(&shared.maxSkippedRatio, "max-skipped-ratio", 0.05, "a max ratio of unparsable files after which a repository is considered to be failed")
+	numWorkers := flag.Int("j", runtime.GOMAXPROCS(0), "the number of repositories to process in parallel")
 	flag.Parse()
 
+	repos := defaultRepoSet()
+	if *reposFile != "" {
+		var err error
+		repos, err = loadRepoConfig(*reposFile)
+		if err != nil {
+			log.Fatalf("load repositories list: %v", err)
+		}
+	}
+	if err := repos.validate(); err != nil {
+		panic(err)
+	}
+	if *lockFile != "" {
+		lock, err := loadCorpusLock(*lockFile)
+		if err != nil {
+			log.Fatalf("load lockfile: %v", err)
+		}
+		repos, err = repos.pinned(lock)
+		if err != nil {
+			log.Fatalf("apply lockfile: %v", err)
+		}
+	}
+	if shared.maxSkippedRatio < 0 || shared.maxSkippedRatio > 1 {
+		log.Fatalf("-max-skipped-ratio: expected a value in [0, 1] range, got %v", shared.maxSkippedRatio)
+	}
+	if *numWorkers < 1 {
+		log.Fatalf("-j: expected a positive number of workers, got %d", *numWorkers)
+	}
+
 	if err := os.MkdirAll(*outputDir, os.ModePerm); err != nil {
 		panic(err)
 	}
 
-	ctx.outDir = *outputDir
-	ctx.numRepos = len(repositoryList)
+	shared.outDir = *outputDir
+	shared.compress = !*noCompression
+	shared.numRepos = len(repos.repos)
+	shared.meta.WithComments = *withComments
 
-	for i, repo := range repositoryList {
-		if err := validateRepo(repo); err != nil {
-			name := repo.name
-			if name == "" {
-				name = fmt.Sprintf("repo[%d]", i)
-			}
-			panic(fmt.Sprintf("%s: %v", name, err))
+	if *incremental {
+		var err error
+		shared.prevRepos, err = loadPrevCorpus(shared)
+		if err != nil {
+			log.Printf("can't do an incremental build: %v", err)
 		}
 	}
 
-	for i, s := range repositoryList {
-		ctx.i = i + 1
-		ctx.repo = s
-		ctx.logDebugf("start loading (%d/%d)", i+1, ctx.numRepos)
-		compress := !*noCompression
-		suffix := ".tar"
-		if compress {
-			suffix += ".gz"
-		}
-		f, err := os.Create(filepath.Join(ctx.outDir, s.name+suffix))
-		if err != nil {
-			ctx.logErrorf("create output file: %v", err)
-			continue
-		}
-		ctx.tar = newTarBuilder(f, compress)
-		meta := collectFiles(ctx)
-		if err := ctx.tar.Flush(); err != nil {
-			ctx.logErrorf("flush output file: %v", err)
-			continue
-		}
+	// Every worker writes only to its own results slot,
+	// so the repositories order doesn't depend on the scheduling.
+	results := make([]*RepositoryMeta, len(repos.repos))
+	reports := make([]*repoBuildReport, len(repos.repos))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < *numWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				ctx := &context{
+					sharedContext: shared,
+					i:             i + 1,
+					repo:          repos.repos[i],
+					report:        &repoBuildReport{Name: repos.repos[i].name},
+				}
+				results[i] = processRepo(ctx)
+				reports[i] = ctx.report
+			}
+		}()
+	}
+	for i := range repos.repos {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, meta := range results {
 		if meta != nil {
-			ctx.meta.Repositories = append(ctx.meta.Repositories, meta)
+			shared.meta.Repositories = append(shared.meta.Repositories, meta)
 		}
 	}
+	numDuplicates := markDuplicates(shared.meta.Repositories)
+	shared.meta.Dependencies = buildDependencyGraph(shared.meta.Repositories)
+	for i, meta := range results {
+		reports[i].setMeta(meta)
+	}
 
-	metaFilename := filepath.Join(ctx.outDir, "corpus.json")
+	metaFilename := filepath.Join(shared.outDir, "corpus.json")
 	var metaFileData bytes.Buffer
-	ctx.meta.WriteJSON(&metaFileData, 0)
+	shared.meta.WriteJSON(&metaFileData, 0)
 	if err := os.WriteFile(metaFilename, metaFileData.Bytes(), 0o666); err != nil {
 		panic(err)
 	}
+	lockFilename := filepath.Join(shared.outDir, "corpus.lock.json")
+	if err := newCorpusLock(shared.meta).WriteFile(lockFilename); err != nil {
+		panic(err)
+	}
+
+	reportFilename := filepath.Join(shared.outDir, "build-report.json")
+	report := newBuildReport(shared, reports, time.Since(startTime))
+	if err := report.WriteFile(reportFilename); err != nil {
+		panic(err)
+	}
+
+	if shared.numWarnings != 0 {
+		log.Printf("warnings: %d", shared.numWarnings)
+	}
+	if shared.numErrors != 0 {
+		log.Printf("errors: %d", shared.numErrors)
+	}
+	if shared.numSkippedFiles != 0 {
+		numRepos := 0
+		for _, meta := range shared.meta.Repositories {
+			if len(meta.SkippedFiles) != 0 {
+				numRepos++
+			}
+		}
+		log.Printf("skipped files: %d (in %d included repositories)", shared.numSkippedFiles, numRepos)
+	}
 
-	if ctx.numWarnings != 0 {
-		log.Printf("warnings: %d", ctx.numWarnings)
+	if numDuplicates != 0 {
+		log.Printf("duplicate files: %d", numDuplicates)
 	}
-	if ctx.numErrors != 0 {
-		log.Printf("errors: %d", ctx.numErrors)
+	if len(shared.meta.Dependencies) != 0 {
+		log.Printf("repository dependencies: %d", len(shared.meta.Dependencies))
 	}
 
-	avgDepth := ctx.totalDepth / ctx.numFiles
-	log.Printf("max file depth: %d", ctx.maxDepth)
-	log.Printf("avg file depth: %d", avgDepth)
+	if shared.numFiles != 0 {
+		avgDepth := shared.totalDepth / shared.numFiles
+		log.Printf("max file depth: %d", shared.maxDepth)
+		log.Printf("avg file depth: %d", avgDepth)
+	}
 
 	exitCode := 0
-	if ctx.numErrors != 0 {
+	if shared.numErrors != 0 {
 		exitCode = 1
 	}
 	os.Exit(exitCode)
 }
 
-type context struct {
-	i        int
+// processRepo builds the ctx.repo archives and returns its metadata.
+// It returns nil if the repository can't be processed.
+func processRepo(ctx *context) *RepositoryMeta {
+	ctx.logDebugf("start loading (%d/%d)", ctx.i, ctx.numRepos)
+	if ctx.prevRepos != nil {
+		// This check must happen before the output files are truncated.
+		if meta := reusePrevMeta(ctx, ctx.prevRepos); meta != nil {
+			ctx.logDebugf("unchanged since the previous build, reusing it")
+			ctx.report.Status = "reused"
+			for _, f := range meta.Files {
+				ctx.addFileDepth(f.MaxDepth)
+			}
+			atomic.AddInt64(&ctx.numFiles, int64(len(meta.Files)))
+			return meta
+		}
+	}
+
+	ctx.report.Status = "built"
+	outputFiles := ctx.outputFiles()
+	f, err := os.Create(outputFiles[0])
+	if err != nil {
+		ctx.logErrorf("create output file: %v", err)
+		return nil
+	}
+	defer f.Close()
+	ctx.tar = newTarBuilder(f, ctx.compress)
+	if ctx.meta.WithComments {
+		f, err := os.Create(outputFiles[3])
+		if err != nil {
+			ctx.logErrorf("create comments output file: %v", err)
+			return nil
+		}
+		defer f.Close()
+		ctx.commentsTar = newTarBuilder(f, ctx.compress)
+	}
+	meta := collectFiles(ctx)
+	flushStart := time.Now()
+	defer func() {
+		ctx.report.Timings.Archive += durationMillis(time.Since(flushStart))
+	}()
+	if err := ctx.tar.Flush(); err != nil {
+		ctx.logErrorf("flush output file: %v", err)
+		return nil
+	}
+	if ctx.commentsTar != nil {
+		if err := ctx.commentsTar.Flush(); err != nil {
+			ctx.logErrorf("flush comments output file: %v", err)
+			return nil
+		}
+	}
+	return meta
+}
+
+// sharedContext is a state that is shared between all repository workers.
+// The counters are updated atomically.
+type sharedContext struct {
 	numRepos int
 
+	// meta is only written by the main goroutine
+	// after all workers are finished.
 	meta *CorpusMeta
-	repo *repository
-	tar  *tarBuilder
+
+	// prevRepos is a read-only previous build metadata,
+	// it's only set for the incremental builds.
+	prevRepos map[string]*RepositoryMeta
 
 	tmpDir string
 
-	outDir  string
-	verbose bool
+	// modProxy is a module zips location: either a local directory
+	// with a GOPROXY layout (like GOMODCACHE/cache/download) or an http(s) URL.
+	modProxy string
+
+	outDir   string
+	verbose  bool
+	compress bool
 
 	maxDepth   int64
 	totalDepth int64
 	numFiles   int64
 
-	numErrors   int
-	numWarnings int
+	numErrors   int64
+	numWarnings int64
+
+	// maxSkippedRatio is a max ratio of the unparsable repository files.
+	// If it's exceeded, the repository is not included into the corpus.
+	maxSkippedRatio float64
+	numSkippedFiles int64
+}
+
+// context is a per-repository worker state.
+type context struct {
+	*sharedContext
+
+	i int
+
+	repo *repository
+	tar  *tarBuilder
+
+	// commentsTar is an optional archive that receives the original
+	// sources, so the comments can be matched by the search engine.
+	commentsTar *tarBuilder
+
+	// report collects the repository build stats and log messages.
+	report *repoBuildReport
+}
+
+func (ctx *sharedContext) addFileDepth(depth int) {
+	atomic.AddInt64(&ctx.totalDepth, int64(depth))
+	for {
+		maxDepth := atomic.LoadInt64(&ctx.maxDepth)
+		if int64(depth) <= maxDepth {
+			break
+		}
+		if atomic.CompareAndSwapInt64(&ctx.maxDepth, maxDepth, int64(depth)) {
+			break
+		}
+	}
+}
+
+// outputFiles returns the current repository output file names.
+// The order is: sources archive, identifiers index, declarations index,
+// comments archive (if enabled).
+func (ctx *context) outputFiles() []string {
+	suffix := ""
+	if ctx.compress {
+		suffix = ".gz"
+	}
+	base := filepath.Join(ctx.outDir, ctx.repo.name)
+	files := []string{
+		base + ".tar" + suffix,
+		base + ".idents.json" + suffix,
+		base + ".decls.json" + suffix,
+	}
+	if ctx.meta.WithComments {
+		files = append(files, base+".comments.tar"+suffix)
+	}
+	return files
+}
+
+// writeIndexFile writes the current repository index to
+// the given output file (gzipped unless compression is disabled).
+// See outputFiles for the index file names.
+func (ctx *context) writeIndexFile(filename string, idx interface{ WriteJSON(io.Writer) error }) error {
+	var buf bytes.Buffer
+	if ctx.compress {
+		gz := gzip.NewWriter(&buf)
+		if err := idx.WriteJSON(gz); err != nil {
+			return err
+		}
+		if err := gz.Close(); err != nil {
+			return err
+		}
+	} else {
+		if err := idx.WriteJSON(&buf); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(filename, buf.Bytes(), 0o666)
 }
 
 // logDebugf logs a formatted message if verbose mode is enabled.
@@ -124,10 +347,12 @@ func (ctx *context) logWarnf(format string, args ...interface{}) {
 	if ctx.verbose {
 		log.Printf("[%s] WARNING: %s", ctx.repo.name, fmt.Sprintf(format, args...))
 	}
-	ctx.numWarnings++
+	ctx.report.Warnings = append(ctx.report.Warnings, fmt.Sprintf(format, args...))
+	atomic.AddInt64(&ctx.numWarnings, 1)
 }
 
 func (ctx *context) logErrorf(format string, args ...interface{}) {
 	log.Printf("[%s] ERROR: %s", ctx.repo.name, fmt.Sprintf(format, args...))
-	ctx.numErrors++
+	ctx.report.Errors = append(ctx.report.Errors, fmt.Sprintf(format, args...))
+	atomic.AddInt64(&ctx.numErrors, 1)
 }
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"io"
+	"sort"
 
 	"github.com/quasilyte/gocorpus/internal/filebits"
 )
@@ -10,16 +11,56 @@ import (
 // 1 - The initial version.
 // 2 - Added 'Version' to CorpusMeta, 'SLOC' to FileMeta.
 // 3 - Added 'MaxDepth' to FileMeta.
-const corpusVersion = 2
+// 4 - Added 'WithComments' to CorpusMeta.
+// 5 - Added 'Directives' to FileMeta.
+// 6 - Added 'BuildConstraint' and 'FilenameTags' to FileMeta.
+// 7 - Added 'GoVersion' to RepositoryMeta and FileMeta.
+// 8 - Added language feature bits to FileMeta 'Flags'.
+// 9 - Added 'NodeKinds' to FileMeta.
+// 10 - Added 'ContentHash' to RepositoryMeta.
+// 11 - Added 'Source' and 'SourceModule' to RepositoryMeta.
+// 12 - Added 'SkippedFiles' to RepositoryMeta.
+// 13 - Added 'Hash' to FileMeta and IsDuplicate to its 'Flags'.
+// 14 - Added 'Modules' to RepositoryMeta, 'Module' and 'PkgPath' to FileMeta.
+// 15 - Added 'Packages' to RepositoryMeta.
+// 16 - Added 'Toolchain', 'Requires' and 'Replaces' to ModuleMeta, 'Dependencies' to CorpusMeta.
+// 17 - UsesGenericInst, UsesMethodValues and UsesRangeFunc flags are set for the possible uses.
+// 18 - Added MayUse* flags, the matching Uses* flags are only set for the proven uses.
+// 19 - Added 'ConfigHash' to RepositoryMeta.
+const corpusVersion = 19
 
 type CorpusMeta struct {
-	Version      int
+	Version int
+
+	// WithComments reports whether every repository also has
+	// a <name>.comments archive that contains the original sources.
+	WithComments bool
+
 	Repositories []*RepositoryMeta
+
+	// Dependencies is an inter-repository dependency graph.
+	// An edge is added when a module of one repository requires
+	// a module provided by another repository of the corpus.
+	Dependencies []DependencyMeta
+}
+
+type DependencyMeta struct {
+	// From and To are the repository names.
+	From string
+	To   string
+
+	// Module is a required module path.
+	Module string
+
+	// Indirect reports whether all requirements of the
+	// module are marked with the "// indirect" comment.
+	Indirect bool
 }
 
 func (m *CorpusMeta) WriteJSON(w io.Writer, indent int) {
 	w.Write([]byte("{\n"))
 	fmt.Fprintf(w, "\t\"Version\": %d,\n", m.Version)
+	fmt.Fprintf(w, "\t\"WithComments\": %v,\n", m.WithComments)
 	w.Write([]byte("\t\"Repositories\": [\n"))
 	for i, s := range m.Repositories {
 		s.WriteJSON(w, indent+1)
@@ -28,19 +69,125 @@ func (m *CorpusMeta) WriteJSON(w io.Writer, indent int) {
 		}
 		w.Write([]byte("\n"))
 	}
-	w.Write([]byte("\t]\n"))
-	w.Write([]byte("}\n"))
+	w.Write([]byte("\t]"))
+	if len(m.Dependencies) != 0 {
+		w.Write([]byte(",\n\t\"Dependencies\": [\n"))
+		for i, d := range m.Dependencies {
+			fmt.Fprintf(w, "\t\t{\"From\": %q, \"To\": %q, \"Module\": %q, \"Indirect\": %v}", d.From, d.To, d.Module, d.Indirect)
+			if i != len(m.Dependencies)-1 {
+				w.Write([]byte(","))
+			}
+			w.Write([]byte("\n"))
+		}
+		w.Write([]byte("\t]"))
+	}
+	w.Write([]byte("\n}\n"))
 }
 
 type RepositoryMeta struct {
-	Name         string
-	Tags         []string
-	Git          string
-	Commit       string
+	Name string
+	Tags []string
+
+	// Source is a sources backend name: "git", "dir" or "modzip".
+	Source string
+
+	// Git is a git remote URL, only set for the "git" source.
+	Git string
+
+	// SourceModule is a "path@version" module, only set for the "modzip" source.
+	SourceModule string
+
+	// Commit is a sources revision: a commit hash or a module version.
+	// It can be empty for the "dir" sources.
+	Commit string
+
+	GoVersion string
+
+	// ContentHash is a sha256 of all collected file names and contents.
+	// Two builds with the same hash have identical sources.
+	ContentHash string
+
+	// ConfigHash is a sha256 of the repository settings that
+	// select the collected files: src roots and path rules.
+	ConfigHash string
+
 	Size         int
 	MinifiedSize int
 	SLOC         int
 	Files        []FileMeta
+
+	// Modules are the Go modules that govern the repository files.
+	// Nested modules are discovered by their go.mod files.
+	// Omitted if there are no modules (e.g. a GOPATH-style repository).
+	Modules []ModuleMeta
+
+	// Packages group the Files by their directory and package name.
+	Packages []PackageMeta
+
+	// SkippedFiles are the files that were excluded from the corpus
+	// because they can't be parsed. Omitted if there are no such files.
+	SkippedFiles []SkippedFileMeta
+}
+
+type ModuleMeta struct {
+	Path string
+
+	// Dir is a module root relative to the repository root.
+	Dir string
+
+	// Version is only known for the "modzip" sources.
+	Version string
+
+	GoVersion string
+
+	// Toolchain is a go.mod toolchain directive value, like "go1.21.3".
+	Toolchain string
+
+	// Requires and Replaces are the go.mod require and replace directives.
+	// Both are omitted if the go.mod file has no such directives.
+	Requires []ModuleRequireMeta
+	Replaces []ModuleReplaceMeta
+}
+
+type ModuleRequireMeta struct {
+	Path    string
+	Version string
+
+	// Indirect is set for the requirements marked with "// indirect".
+	Indirect bool
+}
+
+type ModuleReplaceMeta struct {
+	// Old is a replaced "path" or "path@version".
+	Old string
+
+	// New is a replacement "path@version" or a local directory.
+	New string
+}
+
+type PackageMeta struct {
+	// Dir is a package directory relative to the repository root.
+	Dir string
+
+	// Path is a package import path.
+	// It's empty if the package doesn't belong to any module.
+	// External test packages have the same path as the package they test.
+	Path string
+
+	Name string
+
+	// IsExternalTest is set for the "_test" suffixed packages.
+	IsExternalTest bool
+
+	SLOC int
+
+	// Files are the indexes in the RepositoryMeta.Files.
+	Files []int
+}
+
+type SkippedFileMeta struct {
+	Name  string
+	Error string
 }
 
 func (m *RepositoryMeta) WriteJSON(w io.Writer, indent int) {
@@ -56,8 +203,13 @@ func (m *RepositoryMeta) WriteJSON(w io.Writer, indent int) {
 		}
 		fmt.Fprintf(w, "],\n")
 	}
+	fmt.Fprintf(w, "%s\"Source\": %q,\n", tabs[indent+2], m.Source)
 	fmt.Fprintf(w, "%s\"Git\": %q,\n", tabs[indent+2], m.Git)
+	fmt.Fprintf(w, "%s\"SourceModule\": %q,\n", tabs[indent+2], m.SourceModule)
 	fmt.Fprintf(w, "%s\"Commit\": %q,\n", tabs[indent+2], m.Commit)
+	fmt.Fprintf(w, "%s\"GoVersion\": %q,\n", tabs[indent+2], m.GoVersion)
+	fmt.Fprintf(w, "%s\"ContentHash\": %q,\n", tabs[indent+2], m.ContentHash)
+	fmt.Fprintf(w, "%s\"ConfigHash\": %q,\n", tabs[indent+2], m.ConfigHash)
 	fmt.Fprintf(w, "%s\"Size\": %d,\n", tabs[indent+2], m.Size)
 	fmt.Fprintf(w, "%s\"MinifiedSize\": %d,\n", tabs[indent+2], m.MinifiedSize)
 	fmt.Fprintf(w, "%s\"SLOC\": %d,\n", tabs[indent+2], m.SLOC)
@@ -69,24 +221,167 @@ func (m *RepositoryMeta) WriteJSON(w io.Writer, indent int) {
 		}
 		w.Write([]byte("\n"))
 	}
-	fmt.Fprintf(w, "%s]\n", tabs[indent+2])
+	fmt.Fprintf(w, "%s]", tabs[indent+2])
+	// The optional sections are preceded by a comma,
+	// so the last written section has no trailing comma.
+	if len(m.Modules) != 0 {
+		fmt.Fprintf(w, ",\n%s\"Modules\": [\n", tabs[indent+2])
+		for i, mod := range m.Modules {
+			mod.WriteJSON(w, indent+3)
+			if i != len(m.Modules)-1 {
+				w.Write([]byte(","))
+			}
+			w.Write([]byte("\n"))
+		}
+		fmt.Fprintf(w, "%s]", tabs[indent+2])
+	}
+	if len(m.Packages) != 0 {
+		fmt.Fprintf(w, ",\n%s\"Packages\": [\n", tabs[indent+2])
+		for i, pkg := range m.Packages {
+			fmt.Fprintf(w, "%s{\"Dir\": %q, \"Path\": %q, \"Name\": %q, \"IsExternalTest\": %v, \"SLOC\": %d, \"Files\": [",
+				tabs[indent+3], pkg.Dir, pkg.Path, pkg.Name, pkg.IsExternalTest, pkg.SLOC)
+			for j, fileIndex := range pkg.Files {
+				if j != 0 {
+					w.Write([]byte(", "))
+				}
+				fmt.Fprintf(w, "%d", fileIndex)
+			}
+			w.Write([]byte("]}"))
+			if i != len(m.Packages)-1 {
+				w.Write([]byte(","))
+			}
+			w.Write([]byte("\n"))
+		}
+		fmt.Fprintf(w, "%s]", tabs[indent+2])
+	}
+	if len(m.SkippedFiles) != 0 {
+		fmt.Fprintf(w, ",\n%s\"SkippedFiles\": [\n", tabs[indent+2])
+		for i, f := range m.SkippedFiles {
+			fmt.Fprintf(w, "%s{\"Name\": %q, \"Error\": %q}", tabs[indent+3], f.Name, f.Error)
+			if i != len(m.SkippedFiles)-1 {
+				w.Write([]byte(","))
+			}
+			w.Write([]byte("\n"))
+		}
+		fmt.Fprintf(w, "%s]", tabs[indent+2])
+	}
+	w.Write([]byte("\n"))
 	fmt.Fprintf(w, "%s}", tabs[indent+1])
 }
 
+func (m *ModuleMeta) WriteJSON(w io.Writer, indent int) {
+	fmt.Fprintf(w, "%s{\"Path\": %q, \"Dir\": %q, \"Version\": %q, \"GoVersion\": %q, \"Toolchain\": %q",
+		tabs[indent], m.Path, m.Dir, m.Version, m.GoVersion, m.Toolchain)
+	if len(m.Requires) != 0 {
+		fmt.Fprintf(w, ",\n%s\"Requires\": [\n", tabs[indent+1])
+		for i, r := range m.Requires {
+			fmt.Fprintf(w, "%s{\"Path\": %q, \"Version\": %q, \"Indirect\": %v}", tabs[indent+2], r.Path, r.Version, r.Indirect)
+			if i != len(m.Requires)-1 {
+				w.Write([]byte(","))
+			}
+			w.Write([]byte("\n"))
+		}
+		fmt.Fprintf(w, "%s]", tabs[indent+1])
+	}
+	if len(m.Replaces) != 0 {
+		fmt.Fprintf(w, ",\n%s\"Replaces\": [\n", tabs[indent+1])
+		for i, r := range m.Replaces {
+			fmt.Fprintf(w, "%s{\"Old\": %q, \"New\": %q}", tabs[indent+2], r.Old, r.New)
+			if i != len(m.Replaces)-1 {
+				w.Write([]byte(","))
+			}
+			w.Write([]byte("\n"))
+		}
+		fmt.Fprintf(w, "%s]", tabs[indent+1])
+	}
+	w.Write([]byte("}"))
+}
+
 type FileMeta struct {
 	Name     string
 	Flags    int
 	SLOC     int
 	MaxDepth int
+
+	// NodeKinds is an encoded nodekind.Set of the file AST.
+	NodeKinds string
+
+	// Hash is a truncated sha256 of the minified file contents.
+	// It's used to find the duplicated files.
+	Hash string
+
+	// Directives maps a //go: directive name to its number of occurrences.
+	// Omitted if there are no directives in the file.
+	Directives map[string]int
+
+	// BuildConstraint is a normalized //go:build expression (without the prefix).
+	// Omitted if the file has no build constraints.
+	BuildConstraint string
+
+	// FilenameTags are the GOOS/GOARCH tags implied by the file name suffix.
+	// Omitted if the file name has no such suffix.
+	FilenameTags []string
+
+	// GoVersion is an effective file language version, like "1.21".
+	// It's derived from the governing go.mod and the file build constraints.
+	// Omitted if the version is unknown.
+	GoVersion string
+
+	// Module is an index in the RepositoryMeta.Modules.
+	// It's only valid if PkgPath is not empty.
+	Module int
+
+	// PkgPath is a file package import path.
+	// Omitted if the file doesn't belong to any module.
+	PkgPath string
 }
 
 func (m *FileMeta) WriteJSON(w io.Writer, indent int) {
-	fmt.Fprintf(w, `%s{"Name": %q, "Flags": %d, "SLOC": %d, "MaxDepth": %d}`, tabs[indent], m.Name, m.Flags, m.SLOC, m.MaxDepth)
+	fmt.Fprintf(w, `%s{"Name": %q, "Flags": %d, "SLOC": %d, "MaxDepth": %d, "NodeKinds": %q, "Hash": %q`, tabs[indent], m.Name, m.Flags, m.SLOC, m.MaxDepth, m.NodeKinds, m.Hash)
+	if len(m.Directives) != 0 {
+		names := make([]string, 0, len(m.Directives))
+		for name := range m.Directives {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		w.Write([]byte(`, "Directives": {`))
+		for i, name := range names {
+			fmt.Fprintf(w, "%q: %d", name, m.Directives[name])
+			if i != len(names)-1 {
+				w.Write([]byte(", "))
+			}
+		}
+		w.Write([]byte("}"))
+	}
+	if m.GoVersion != "" {
+		fmt.Fprintf(w, `, "GoVersion": %q`, m.GoVersion)
+	}
+	if m.PkgPath != "" {
+		fmt.Fprintf(w, `, "Module": %d, "PkgPath": %q`, m.Module, m.PkgPath)
+	}
+	if m.BuildConstraint != "" {
+		fmt.Fprintf(w, `, "BuildConstraint": %q`, m.BuildConstraint)
+	}
+	if len(m.FilenameTags) != 0 {
+		w.Write([]byte(`, "FilenameTags": [`))
+		for i, tag := range m.FilenameTags {
+			fmt.Fprintf(w, "%q", tag)
+			if i != len(m.FilenameTags)-1 {
+				w.Write([]byte(", "))
+			}
+		}
+		w.Write([]byte("]"))
+	}
+	w.Write([]byte("}"))
 }
 
 func newFileMeta(info *repositoryFileInfo) FileMeta {
 	var m FileMeta
 	m.MaxDepth = info.maxDepth
+	m.NodeKinds = info.nodeKinds.Encode()
+	m.Directives = info.directives
+	m.BuildConstraint = info.buildConstraint
+	m.FilenameTags = info.filenameTags
 	if info.isTest {
 		m.Flags |= filebits.IsTest
 	}
@@ -105,6 +400,48 @@ func newFileMeta(info *repositoryFileInfo) FileMeta {
 	if info.importsReflect {
 		m.Flags |= filebits.ImportsReflect
 	}
+	if info.usesGenericDecls {
+		m.Flags |= filebits.UsesGenericDecls
+	}
+	if info.usesGenericInst {
+		m.Flags |= filebits.UsesGenericInst
+	}
+	if info.usesGoroutines {
+		m.Flags |= filebits.UsesGoroutines
+	}
+	if info.usesSelect {
+		m.Flags |= filebits.UsesSelect
+	}
+	if info.usesDefer {
+		m.Flags |= filebits.UsesDefer
+	}
+	if info.usesLabeledBranch {
+		m.Flags |= filebits.UsesLabeledBranch
+	}
+	if info.usesGoto {
+		m.Flags |= filebits.UsesGoto
+	}
+	if info.usesMethodValues {
+		m.Flags |= filebits.UsesMethodValues
+	}
+	if info.usesRangeFunc {
+		m.Flags |= filebits.UsesRangeFunc
+	}
+	if info.usesTypeSwitch {
+		m.Flags |= filebits.UsesTypeSwitch
+	}
+	if info.usesEmbedding {
+		m.Flags |= filebits.UsesEmbedding
+	}
+	if info.mayUseGenericInst {
+		m.Flags |= filebits.MayUseGenericInst
+	}
+	if info.mayUseMethodValues {
+		m.Flags |= filebits.MayUseMethodValues
+	}
+	if info.mayUseRangeFunc {
+		m.Flags |= filebits.MayUseRangeFunc
+	}
 	return m
 }
 
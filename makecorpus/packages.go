@@ -0,0 +1,38 @@
+package main
+
+import (
+	"path"
+	"strings"
+)
+
+// groupPackages builds a package table for the repository files.
+// pkgNames[i] is a package name of the files[i].
+//
+// Files from the same directory that share the package name form a package,
+// so a package and its external test package are reported separately.
+func groupPackages(files []FileMeta, pkgNames []string) []PackageMeta {
+	type packageKey struct {
+		dir  string
+		name string
+	}
+	var packages []PackageMeta
+	index := make(map[packageKey]int)
+	for i, f := range files {
+		key := packageKey{dir: path.Dir(f.Name), name: pkgNames[i]}
+		pkgIndex, ok := index[key]
+		if !ok {
+			pkgIndex = len(packages)
+			index[key] = pkgIndex
+			packages = append(packages, PackageMeta{
+				Dir:            key.dir,
+				Path:           f.PkgPath,
+				Name:           key.name,
+				IsExternalTest: strings.HasSuffix(key.name, "_test") && strings.HasSuffix(f.Name, "_test.go"),
+			})
+		}
+		pkg := &packages[pkgIndex]
+		pkg.SLOC += f.SLOC
+		pkg.Files = append(pkg.Files, i)
+	}
+	return packages
+}
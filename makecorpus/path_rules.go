@@ -0,0 +1,149 @@
+package main
+
+import (
+	"path"
+	"strings"
+)
+
+// defaultExcludes replicate the directories that were always skipped
+// before the per-repository rules were introduced.
+// They are only matched against the directories, so a "_foo.go" file is collected.
+var defaultExcludes = []string{
+	"**/node_modules",
+	"**/_*",
+	"**/testdata",
+	"**/vendor",
+	"**/third_party",
+}
+
+// pathRules decide which repository files are collected.
+//
+// Patterns are matched against the slash-separated paths relative
+// to the repository root. Every pattern segment is a path.Match pattern,
+// a "**" segment matches zero or more path segments.
+//
+// A path is excluded if it or any of its parent directories match
+// an exclude pattern. Include patterns take precedence over the excludes:
+// "third_party/**" makes the third_party files collected again.
+type pathRules struct {
+	include [][]string
+	exclude [][]string
+
+	// dirExclude are the exclude patterns that only match directories.
+	dirExclude [][]string
+}
+
+func newPathRules(repo *repository) *pathRules {
+	var dirExcludes []string
+	if !repo.noDefaultExcludes {
+		for _, pat := range defaultExcludes {
+			if repo.withTestdata && pat == "**/testdata" {
+				continue
+			}
+			dirExcludes = append(dirExcludes, pat)
+		}
+	}
+	return &pathRules{
+		include:    splitGlobs(repo.include),
+		exclude:    splitGlobs(repo.exclude),
+		dirExclude: splitGlobs(dirExcludes),
+	}
+}
+
+// skipDir reports whether the directory can be skipped as a whole.
+func (rules *pathRules) skipDir(rel string) bool {
+	if rel == "." {
+		return false
+	}
+	segs := strings.Split(rel, "/")
+	if !matchAnyGlob(rules.exclude, segs) && !matchAnyGlob(rules.dirExclude, segs) {
+		return false
+	}
+	for _, pat := range rules.include {
+		if globCanMatchUnder(pat, segs) {
+			return false
+		}
+	}
+	return true
+}
+
+// skipFile reports whether the file should not be collected.
+func (rules *pathRules) skipFile(rel string) bool {
+	segs := strings.Split(rel, "/")
+	if matchAnyGlob(rules.include, segs) {
+		return false
+	}
+	for i := 1; i < len(segs); i++ {
+		if matchAnyGlob(rules.exclude, segs[:i]) || matchAnyGlob(rules.dirExclude, segs[:i]) {
+			return true
+		}
+	}
+	return matchAnyGlob(rules.exclude, segs)
+}
+
+func splitGlobs(patterns []string) [][]string {
+	result := make([][]string, len(patterns))
+	for i, pat := range patterns {
+		result[i] = strings.Split(pat, "/")
+	}
+	return result
+}
+
+// validateGlob checks the pattern syntax.
+func validateGlob(pattern string) error {
+	for _, seg := range strings.Split(pattern, "/") {
+		if _, err := path.Match(seg, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func matchAnyGlob(patterns [][]string, segs []string) bool {
+	for _, pat := range patterns {
+		if matchGlob(pat, segs) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchGlob(pat, segs []string) bool {
+	for len(pat) != 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(segs); i++ {
+				if matchGlob(pat[1:], segs[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segs) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], segs[0]); !ok {
+			return false
+		}
+		pat = pat[1:]
+		segs = segs[1:]
+	}
+	return len(segs) == 0
+}
+
+// globCanMatchUnder reports whether pat can match some path inside the dir.
+func globCanMatchUnder(pat, dir []string) bool {
+	for len(dir) != 0 {
+		if len(pat) == 0 {
+			return false
+		}
+		if pat[0] == "**" {
+			return true
+		}
+		if ok, _ := path.Match(pat[0], dir[0]); !ok {
+			return false
+		}
+		pat = pat[1:]
+		dir = dir[1:]
+	}
+	return len(pat) != 0
+}
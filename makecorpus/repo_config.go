@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// repoConfig is a repositories list description that can be loaded
+// from a JSON file instead of the built-in repositoryList.
+//
+// Example:
+//
+//	{
+//	  "tags": ["lib", "net"],
+//	  "repositories": [
+//	    {
+//	      "name": "valyala-fasthttp",
+//	      "tags": ["lib", "net"],
+//	      "git": "https://github.com/valyala/fasthttp.git",
+//	      "src_roots": ["."],
+//	      "ref": "v1.40.0"
+//	    },
+//	    {
+//	      "name": "internal-billing",
+//	      "tags": ["lib"],
+//	      "dir": "/src/billing",
+//	      "src_roots": ["."],
+//	      "include": ["third_party/**"],
+//	      "exclude": ["api/**", "**/*.pb.go"],
+//	      "with_testdata": true
+//	    },
+//	    {
+//	      "name": "x-mod",
+//	      "tags": ["lib"],
+//	      "module": "golang.org/x/mod@v0.14.0",
+//	      "src_roots": ["."]
+//	    }
+//	  ]
+//	}
+//
+// If tags list is omitted, knownRepoTags are used.
+type repoConfig struct {
+	Tags         []string          `json:"tags"`
+	Repositories []repoConfigEntry `json:"repositories"`
+}
+
+type repoConfigEntry struct {
+	Name     string   `json:"name"`
+	Tags     []string `json:"tags"`
+	Git      string   `json:"git"`
+	SrcRoots []string `json:"src_roots"`
+	Ref      string   `json:"ref"`
+	Dir      string   `json:"dir"`
+	Module   string   `json:"module"`
+
+	Include           []string `json:"include"`
+	Exclude           []string `json:"exclude"`
+	WithTestdata      bool     `json:"with_testdata"`
+	NoDefaultExcludes bool     `json:"no_default_excludes"`
+}
+
+// repoSet is a validated list of repositories along with
+// the tags vocabulary they were checked against.
+type repoSet struct {
+	tags  map[string]struct{}
+	repos []*repository
+}
+
+func defaultRepoSet() *repoSet {
+	return &repoSet{
+		tags:  knownRepoTags,
+		repos: repositoryList,
+	}
+}
+
+func loadRepoConfig(filename string) (*repoSet, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var config repoConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&config); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	if len(config.Repositories) == 0 {
+		return nil, fmt.Errorf("%s: empty repositories list", filename)
+	}
+
+	set := &repoSet{tags: knownRepoTags}
+	if len(config.Tags) != 0 {
+		set.tags = make(map[string]struct{}, len(config.Tags))
+		for _, tag := range config.Tags {
+			set.tags[tag] = struct{}{}
+		}
+	}
+	set.repos = make([]*repository, len(config.Repositories))
+	for i, e := range config.Repositories {
+		set.repos[i] = &repository{
+			name:     e.Name,
+			tags:     e.Tags,
+			git:      e.Git,
+			srcRoots: e.SrcRoots,
+			ref:      e.Ref,
+			dir:      e.Dir,
+			module:   e.Module,
+
+			include:           e.Include,
+			exclude:           e.Exclude,
+			withTestdata:      e.WithTestdata,
+			noDefaultExcludes: e.NoDefaultExcludes,
+		}
+	}
+	return set, nil
+}
+
+// validate runs validateRepo for every repository and checks
+// that repository names are unique.
+func (set *repoSet) validate() error {
+	seen := make(map[string]struct{}, len(set.repos))
+	for i, repo := range set.repos {
+		name := repo.name
+		if name == "" {
+			name = fmt.Sprintf("repo[%d]", i)
+		}
+		if err := validateRepo(repo, set.tags); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		if _, ok := seen[repo.name]; ok {
+			return fmt.Errorf("%s: duplicated repo name", name)
+		}
+		seen[repo.name] = struct{}{}
+	}
+	return nil
+}
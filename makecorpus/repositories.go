@@ -1,10 +1,68 @@
 package main
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// repository describes a corpus code source.
+// Exactly one of git, dir and module should be set,
+// it selects the sources backend (see sourceBackend).
 type repository struct {
 	name     string
 	tags     []string
 	git      string
 	srcRoots []string
+
+	// dir is a local directory (or an existing checkout) path.
+	dir string
+
+	// module is a "path@version" Go module that is
+	// fetched as a zip from a module proxy or GOMODCACHE.
+	module string
+
+	// include and exclude are the path glob rules, see pathRules.
+	// Exclude patterns are added to the defaultExcludes.
+	include []string
+	exclude []string
+
+	// withTestdata removes testdata from the defaultExcludes.
+	withTestdata bool
+
+	// noDefaultExcludes disables the defaultExcludes.
+	noDefaultExcludes bool
+
+	// ref is an optional git commit hash or tag to build the corpus from.
+	// If empty, the default branch HEAD is used.
+	ref string
+
+	// contentHash is an expected RepositoryMeta.ContentHash value.
+	// It's only set when the corpus is rebuilt from a lockfile.
+	contentHash string
+}
+
+func (repo *repository) sourceName() string {
+	switch {
+	case repo.dir != "":
+		return "dir"
+	case repo.module != "":
+		return "modzip"
+	default:
+		return "git"
+	}
+}
+
+// configHash returns a hash of the settings that select the collected files.
+// A previous build can only be reused if its config hash is the same.
+func (repo *repository) configHash() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "src_roots=%q\n", repo.srcRoots)
+	fmt.Fprintf(h, "include=%q\n", repo.include)
+	fmt.Fprintf(h, "exclude=%q\n", repo.exclude)
+	fmt.Fprintf(h, "with_testdata=%v\n", repo.withTestdata)
+	fmt.Fprintf(h, "no_default_excludes=%v\n", repo.noDefaultExcludes)
+	return hex.EncodeToString(h.Sum(nil))
 }
 
 var repositoryList = []*repository{
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// sourceBackend is a way to obtain the repository sources.
+type sourceBackend interface {
+	// fetch makes the repository sources available in a local directory.
+	// The returned sources cleanup func must be called when the sources are no longer needed.
+	fetch(ctx *context) (*fetchedSources, error)
+
+	// resolveRevision returns the revision that fetch would give without fetching the sources.
+	// An empty revision means that it can't be known in advance.
+	resolveRevision(ctx *context) (string, error)
+}
+
+type fetchedSources struct {
+	// dir is a sources root directory.
+	dir string
+
+	// revision is a commit hash or a module version.
+	// It's empty if the sources have no revision.
+	revision string
+
+	cleanup func()
+}
+
+func newSourceBackend(repo *repository) sourceBackend {
+	switch {
+	case repo.dir != "":
+		return &dirSource{dir: repo.dir}
+	case repo.module != "":
+		path, version, _ := strings.Cut(repo.module, "@")
+		return &modzipSource{path: path, version: version}
+	default:
+		return &gitSource{url: repo.git, ref: repo.ref}
+	}
+}
+
+// gitSource fetches the sources from a git remote.
+// If ref is set, only that revision is fetched.
+type gitSource struct {
+	url string
+	ref string
+}
+
+func (src *gitSource) fetch(ctx *context) (*fetchedSources, error) {
+	// We're going to clone sources to a tmp dir.
+	// This dir will be removed when we're finished.
+	dir := filepath.Join(ctx.tmpDir, ctx.repo.name+"-tmp")
+	cleanup := func() { os.RemoveAll(dir) }
+	if err := src.checkout(ctx, dir); err != nil {
+		cleanup()
+		return nil, err
+	}
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").CombinedOutput()
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("git rev-parse: %v: %s", err, out)
+	}
+	return &fetchedSources{
+		dir:      dir,
+		revision: strings.TrimSpace(string(out)),
+		cleanup:  cleanup,
+	}, nil
+}
+
+func (src *gitSource) checkout(ctx *context, dir string) error {
+	if src.ref == "" {
+		ctx.logDebugf("doing a git clone")
+		out, err := exec.Command("git", "clone", "--depth=1", src.url, dir).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("git clone: %v: %s", err, out)
+		}
+		return nil
+	}
+
+	// git clone can't fetch an arbitrary commit, so we build
+	// the repository step by step instead.
+	ctx.logDebugf("doing a git fetch of %s", src.ref)
+	steps := []struct {
+		name string
+		args []string
+	}{
+		{"init", []string{"init", "--quiet", dir}},
+		{"remote add", []string{"-C", dir, "remote", "add", "origin", src.url}},
+		{"fetch", []string{"-C", dir, "fetch", "--quiet", "--depth=1", "origin", src.ref}},
+		{"checkout", []string{"-C", dir, "checkout", "--quiet", "FETCH_HEAD"}},
+	}
+	for _, step := range steps {
+		out, err := exec.Command("git", step.args...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("git %s: %v: %s", step.name, err, out)
+		}
+	}
+	return nil
+}
+
+func (src *gitSource) resolveRevision(ctx *context) (string, error) {
+	if isCommitHash(src.ref) {
+		return src.ref, nil
+	}
+	ref := src.ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	out, err := exec.Command("git", "ls-remote", src.url, ref).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git ls-remote: %v: %s", err, out)
+	}
+	refs := make(map[string]string)
+	for _, line := range strings.Split(string(out), "\n") {
+		commit, name, ok := strings.Cut(line, "\t")
+		if ok {
+			refs[name] = commit
+		}
+	}
+	// Annotated tags are listed twice: the peeled ^{} version points to the commit.
+	candidates := []string{
+		"refs/tags/" + ref + "^{}",
+		"refs/tags/" + ref,
+		"refs/heads/" + ref,
+		ref,
+	}
+	for _, name := range candidates {
+		if commit, ok := refs[name]; ok {
+			return commit, nil
+		}
+	}
+	return "", fmt.Errorf("%s: ref not found", ref)
+}
+
+// dirSource uses a local directory as is.
+// If it's a git checkout, its HEAD commit is used as a revision.
+//
+// The directory is never modified or removed.
+type dirSource struct {
+	dir string
+}
+
+func (src *dirSource) fetch(ctx *context) (*fetchedSources, error) {
+	stat, err := os.Stat(src.dir)
+	if err != nil {
+		return nil, err
+	}
+	if !stat.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", src.dir)
+	}
+	ctx.logDebugf("using local sources from %s", src.dir)
+	return &fetchedSources{
+		dir:      src.dir,
+		revision: src.headCommit(),
+		cleanup:  func() {},
+	}, nil
+}
+
+func (src *dirSource) resolveRevision(ctx *context) (string, error) {
+	// Uncommitted changes make the HEAD commit meaningless.
+	out, err := exec.Command("git", "-C", src.dir, "status", "--porcelain").Output()
+	if err != nil || len(bytes.TrimSpace(out)) != 0 {
+		return "", nil
+	}
+	return src.headCommit(), nil
+}
+
+func (src *dirSource) headCommit() string {
+	out, err := exec.Command("git", "-C", src.dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// modzipSource extracts the sources from a Go module zip.
+// The zip is located using the GOPROXY protocol layout,
+// see ctx.modProxy for the possible proxy locations.
+type modzipSource struct {
+	path    string
+	version string
+}
+
+func (src *modzipSource) fetch(ctx *context) (*fetchedSources, error) {
+	data, err := src.download(ctx.modProxy)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%s@%s: %v", src.path, src.version, err)
+	}
+
+	dir := filepath.Join(ctx.tmpDir, ctx.repo.name+"-tmp")
+	cleanup := func() { os.RemoveAll(dir) }
+	ctx.logDebugf("extracting %s@%s module zip", src.path, src.version)
+	if err := src.extract(zr, dir); err != nil {
+		cleanup()
+		return nil, err
+	}
+	return &fetchedSources{
+		dir:      dir,
+		revision: src.version,
+		cleanup:  cleanup,
+	}, nil
+}
+
+func (src *modzipSource) resolveRevision(ctx *context) (string, error) {
+	// Module versions are immutable.
+	return src.version, nil
+}
+
+func (src *modzipSource) download(proxy string) ([]byte, error) {
+	escapedPath, err := escapeModulePath(src.path)
+	if err != nil {
+		return nil, err
+	}
+	escapedVersion, err := escapeModulePath(src.version)
+	if err != nil {
+		return nil, err
+	}
+	zipPath := escapedPath + "/@v/" + escapedVersion + ".zip"
+
+	if !strings.HasPrefix(proxy, "http://") && !strings.HasPrefix(proxy, "https://") {
+		return os.ReadFile(filepath.Join(proxy, filepath.FromSlash(zipPath)))
+	}
+	resp, err := http.Get(strings.TrimSuffix(proxy, "/") + "/" + zipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", zipPath, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (src *modzipSource) extract(zr *zip.Reader, dir string) error {
+	prefix := src.path + "@" + src.version + "/"
+	for _, f := range zr.File {
+		if !strings.HasPrefix(f.Name, prefix) {
+			return fmt.Errorf("%s: unexpected file outside of the module root", f.Name)
+		}
+		name := filepath.FromSlash(strings.TrimPrefix(f.Name, prefix))
+		if !isLocalPath(name) {
+			return fmt.Errorf("%s: invalid file name", f.Name)
+		}
+		if strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		dst := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+			return err
+		}
+		if err := extractZipFile(f, dst); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractZipFile(f *zip.File, dst string) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0o644)
+}
+
+// escapeModulePath implements the module proxy case encoding:
+// every upper-case letter is replaced by '!' followed by its lower-case version.
+func escapeModulePath(s string) (string, error) {
+	var buf strings.Builder
+	for _, ch := range s {
+		if ch == '!' || ch >= unicode.MaxASCII {
+			return "", fmt.Errorf("%q: invalid module path or version", s)
+		}
+		if 'A' <= ch && ch <= 'Z' {
+			buf.WriteByte('!')
+			ch = unicode.ToLower(ch)
+		}
+		buf.WriteRune(ch)
+	}
+	return buf.String(), nil
+}
+
+// defaultModProxy returns the GOMODCACHE download dir,
+// it has the same layout as a GOPROXY.
+func defaultModProxy() string {
+	modCache := os.Getenv("GOMODCACHE")
+	if modCache == "" {
+		gopath := os.Getenv("GOPATH")
+		if gopath == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return ""
+			}
+			gopath = filepath.Join(home, "go")
+		}
+		modCache = filepath.Join(filepath.SplitList(gopath)[0], "pkg", "mod")
+	}
+	return filepath.Join(modCache, "cache", "download")
+}
+
+// isLocalPath reports whether a relative path doesn't escape its root.
+func isLocalPath(path string) bool {
+	if path == "" || filepath.IsAbs(path) {
+		return false
+	}
+	path = filepath.Clean(path)
+	return path != ".." && !strings.HasPrefix(path, ".."+string(filepath.Separator))
+}
+
+func isCommitHash(s string) bool {
+	if len(s) != 40 {
+		return false
+	}
+	for _, ch := range s {
+		if !strings.ContainsRune("0123456789abcdef", ch) {
+			return false
+		}
+	}
+	return true
+}
@@ -23,15 +23,16 @@ func newTarBuilder(w io.Writer, compress bool) *tarBuilder {
 }
 
 func (b *tarBuilder) Flush() error {
+	// The tar trailer must be written before the gzip stream is closed.
+	if err := b.tar.Close(); err != nil {
+		return err
+	}
 	if b.gz != nil {
-		if err := b.gz.Flush(); err != nil {
-			return err
-		}
 		if err := b.gz.Close(); err != nil {
 			return err
 		}
 	}
-	return b.tar.Close()
+	return nil
 }
 
 func (b *tarBuilder) AddFile(filename string, mode int64, data []byte) error {
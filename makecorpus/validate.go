@@ -33,24 +33,49 @@ var knownRepoTags = map[string]struct{}{
 	"ebpf":       {},
 }
 
-func validateRepo(repo *repository) error {
+func validateRepo(repo *repository, knownTags map[string]struct{}) error {
 	if repo.name == "" {
 		return errors.New("empty repo name")
 	}
-	if repo.git == "" {
+	numSources := 0
+	for _, s := range []string{repo.git, repo.dir, repo.module} {
+		if s != "" {
+			numSources++
+		}
+	}
+	switch {
+	case numSources == 0:
 		return errors.New("empty repo git")
+	case numSources > 1:
+		return errors.New("only one of git, dir and module can be set")
+	case repo.git != "":
+		if !strings.HasSuffix(repo.git, ".git") {
+			return errors.New("git link doesn't end with '.git'")
+		}
+	case repo.module != "":
+		path, version, ok := strings.Cut(repo.module, "@")
+		if !ok || path == "" || version == "" {
+			return errors.New("module should be in path@version form")
+		}
 	}
-	if !strings.HasSuffix(repo.git, ".git") {
-		return errors.New("git link doesn't end with '.git'")
+	if repo.ref != "" && repo.git == "" {
+		return errors.New("ref can only be used with git")
 	}
 	if len(repo.srcRoots) == 0 {
 		return errors.New("empty repo src roots list")
 	}
+	for _, patterns := range [][]string{repo.include, repo.exclude} {
+		for _, pat := range patterns {
+			if err := validateGlob(pat); err != nil {
+				return fmt.Errorf("%s: %v", pat, err)
+			}
+		}
+	}
 	if len(repo.tags) == 0 {
 		return errors.New("empty repo tags list")
 	}
 	for _, tag := range repo.tags {
-		if _, ok := knownRepoTags[tag]; !ok {
+		if _, ok := knownTags[tag]; !ok {
 			return fmt.Errorf("unknown %s tag", tag)
 		}
 	}
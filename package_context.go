@@ -0,0 +1,103 @@
+package main
+
+import (
+	"go/ast"
+)
+
+// packageContext holds the package-wide data for the pkg.* filters.
+//
+// Like everything else in the filters, it's purely syntactical:
+// methods are grouped by the receiver type name and references
+// are counted by the identifier names.
+type packageContext struct {
+	// methods maps a receiver type name to its method names.
+	methods map[string]map[string]struct{}
+
+	// refs maps a name to the number of its uses.
+	// Top-level declaration names are not counted as uses.
+	refs map[string]int
+}
+
+func newPackageContext(files []*ast.File) *packageContext {
+	ctx := &packageContext{
+		methods: make(map[string]map[string]struct{}),
+		refs:    make(map[string]int),
+	}
+
+	declNames := make(map[*ast.Ident]struct{})
+	for _, f := range files {
+		declNames[f.Name] = struct{}{}
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				declNames[decl.Name] = struct{}{}
+				if decl.Recv != nil && len(decl.Recv.List) != 0 {
+					typeName := typeNameOf(decl.Recv.List[0].Type)
+					if ctx.methods[typeName] == nil {
+						ctx.methods[typeName] = make(map[string]struct{})
+					}
+					ctx.methods[typeName][decl.Name.Name] = struct{}{}
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						declNames[spec.Name] = struct{}{}
+					case *ast.ValueSpec:
+						for _, name := range spec.Names {
+							declNames[name] = struct{}{}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	for _, f := range files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			ident, ok := n.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			if _, ok := declNames[ident]; !ok {
+				ctx.refs[ident.Name]++
+			}
+			return true
+		})
+	}
+
+	return ctx
+}
+
+func (ctx *packageContext) hasMethod(typ ast.Node, name string) bool {
+	_, ok := ctx.methods[typeNameOf(typ)][name]
+	return ok
+}
+
+func (ctx *packageContext) references(n ast.Node) bool {
+	name := typeNameOf(n)
+	return name != "" && ctx.refs[name] != 0
+}
+
+// typeNameOf returns a name of the (possibly pointer or generic) type expression.
+// For the other nodes, it returns an identifier name if n is an identifier.
+func typeNameOf(n ast.Node) string {
+	switch n := n.(type) {
+	case *ast.Ident:
+		return n.Name
+	case *ast.StarExpr:
+		return typeNameOf(n.X)
+	case *ast.ParenExpr:
+		return typeNameOf(n.X)
+	case *ast.IndexExpr:
+		return typeNameOf(n.X)
+	case *ast.IndexListExpr:
+		return typeNameOf(n.X)
+	case *ast.TypeSpec:
+		return n.Name.Name
+	case *ast.FuncDecl:
+		return n.Name.Name
+	default:
+		return ""
+	}
+}
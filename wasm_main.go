@@ -6,15 +6,27 @@ import (
 	"go/parser"
 	"go/token"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
 	"syscall/js"
 
+	"github.com/quasilyte/gocorpus/internal/buildtags"
 	"github.com/quasilyte/gocorpus/internal/filebits"
 	"github.com/quasilyte/gocorpus/internal/filters"
+	"github.com/quasilyte/gocorpus/internal/goversion"
+	"github.com/quasilyte/gocorpus/internal/imports"
+	"github.com/quasilyte/gocorpus/internal/nodekind"
+	"github.com/quasilyte/gocorpus/internal/pattern"
+	"github.com/quasilyte/gocorpus/internal/structtag"
 	"github.com/quasilyte/gogrep"
 )
 
 func main() {
 	js.Global().Set("gogrep", js.FuncOf(jsGogrep))
+	js.Global().Set("gogrepPackage", js.FuncOf(jsGogrepPackage))
+	js.Global().Set("gogrepDecls", js.FuncOf(jsGogrepDecls))
+	js.Global().Set("gogrepPatternIdents", js.FuncOf(jsPatternIdents))
 
 	<-make(chan bool)
 }
@@ -97,6 +109,62 @@ func isConstExpr(e ast.Expr) bool {
 
 var badExpr = &ast.BadExpr{}
 
+// capturedStructTags returns the unquoted struct tags captured by the var.
+// The var can be bound to a tag string literal, a struct field,
+// a list of fields (like $*fields) or a struct type;
+// the StructTag filters are satisfied if any of the tags matches.
+// A field without a tag has an empty tag, like in reflect.StructField.
+func capturedStructTags(m gogrep.MatchData, name string) []string {
+	n, ok := m.CapturedByName(name)
+	if !ok {
+		return nil
+	}
+	var fields []*ast.Field
+	switch n := n.(type) {
+	case *ast.BasicLit:
+		if tag, ok := unquoteStructTag(n); ok {
+			return []string{tag}
+		}
+		return nil
+	case *ast.Field:
+		fields = []*ast.Field{n}
+	case *ast.FieldList:
+		fields = n.List
+	case *ast.StructType:
+		fields = n.Fields.List
+	case *gogrep.NodeSlice:
+		if n.Kind == gogrep.FieldNodeSlice {
+			fields = n.GetFieldSlice()
+		}
+	}
+	var tags []string
+	for _, field := range fields {
+		if field.Tag == nil {
+			tags = append(tags, "")
+			continue
+		}
+		if tag, ok := unquoteStructTag(field.Tag); ok {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func unquoteStructTag(lit *ast.BasicLit) (string, bool) {
+	if lit.Kind != token.STRING {
+		return "", false
+	}
+	tag, err := strconv.Unquote(lit.Value)
+	return tag, err == nil
+}
+
+// decodeStructTagArg decodes the key:"value" StructTag filter argument.
+func decodeStructTagArg(s string) (key, value string) {
+	key, quoted, _ := strings.Cut(s, ":")
+	value, _ = strconv.Unquote(quoted)
+	return key, value
+}
+
 func getMatchExpr(m gogrep.MatchData, name string) ast.Expr {
 	n, ok := m.CapturedByName(name)
 	if !ok {
@@ -116,16 +184,125 @@ func checkBasicLit(n ast.Expr, kind token.Token) bool {
 	return false
 }
 
-func applyFilter(f *filters.Expr, n ast.Node, m gogrep.MatchData) bool {
+// matchContext holds the per-file data that is needed
+// by the filters that can't be applied to the captured nodes alone.
+type matchContext struct {
+	fset    *token.FileSet
+	file    *ast.File
+	imports *imports.Table
+
+	// pkg is only available in the package mode.
+	pkg *packageContext
+
+	// stack contains the matched node parents, the root comes first.
+	stack []ast.Node
+
+	// suppressions is lazily initialized by the first
+	// match.IsSuppressed filter.
+	suppressions map[int][]suppression
+
+	regexps map[string]*regexp.Regexp
+}
+
+func (ctx *matchContext) getRegexp(pattern string) *regexp.Regexp {
+	re, ok := ctx.regexps[pattern]
+	if !ok {
+		// The pattern is validated during the filter compilation.
+		re = regexp.MustCompile(pattern)
+		ctx.regexps[pattern] = re
+	}
+	return re
+}
+
+func (ctx *matchContext) hasComment(n ast.Node, re *regexp.Regexp) bool {
+	fromLine := ctx.fset.Position(n.Pos()).Line
+	toLine := ctx.fset.Position(n.End()).Line
+	for _, group := range ctx.file.Comments {
+		if ctx.fset.Position(group.End()).Line < fromLine {
+			continue
+		}
+		if ctx.fset.Position(group.Pos()).Line > toLine {
+			break
+		}
+		for _, c := range group.List {
+			line := ctx.fset.Position(c.Pos()).Line
+			if line >= fromLine && line <= toLine && re.MatchString(c.Text) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (ctx *matchContext) enclosingFunc(n ast.Node) *ast.FuncDecl {
+	if fn, ok := n.(*ast.FuncDecl); ok {
+		return fn
+	}
+	for i := len(ctx.stack) - 1; i >= 0; i-- {
+		if fn, ok := ctx.stack[i].(*ast.FuncDecl); ok {
+			return fn
+		}
+	}
+	return nil
+}
+
+// isSuppressed reports whether n is suppressed by a linter directive
+// that is located on the n line or on the line of the enclosing
+// statement or declaration (including their doc comments).
+func (ctx *matchContext) isSuppressed(n ast.Node, linter string) bool {
+	if ctx.suppressions == nil {
+		ctx.suppressions = collectSuppressions(ctx.fset, ctx.file)
+	}
+	if len(ctx.suppressions) == 0 {
+		return false
+	}
+
+	suppressedAt := func(pos token.Pos) bool {
+		for _, s := range ctx.suppressions[ctx.fset.Position(pos).Line] {
+			if s.Suppresses(linter) {
+				return true
+			}
+		}
+		return false
+	}
+	suppressedNode := func(n ast.Node) bool {
+		if suppressedAt(n.Pos()) {
+			return true
+		}
+		if doc := nodeDoc(n); doc != nil {
+			for _, c := range doc.List {
+				if suppressedAt(c.Pos()) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	if suppressedNode(n) {
+		return true
+	}
+	for i := len(ctx.stack) - 1; i >= 0; i-- {
+		switch parent := ctx.stack[i].(type) {
+		case ast.Stmt, ast.Decl, ast.Spec, *ast.Field:
+			if suppressedNode(parent) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func applyFilter(ctx *matchContext, f *filters.Expr, n ast.Node, m gogrep.MatchData) bool {
 	switch f.Op {
 	case filters.OpNot:
-		return !applyFilter(f.Args[0], n, m)
+		return !applyFilter(ctx, f.Args[0], n, m)
 
 	case filters.OpAnd:
-		return applyFilter(f.Args[0], n, m) && applyFilter(f.Args[1], n, m)
+		return applyFilter(ctx, f.Args[0], n, m) && applyFilter(ctx, f.Args[1], n, m)
 
 	case filters.OpOr:
-		return applyFilter(f.Args[0], n, m) || applyFilter(f.Args[1], n, m)
+		return applyFilter(ctx, f.Args[0], n, m) || applyFilter(ctx, f.Args[1], n, m)
 
 	case filters.OpVarIsConst:
 		v, ok := m.CapturedByName(f.Str)
@@ -158,6 +335,59 @@ func applyFilter(f *filters.Expr, n ast.Node, m gogrep.MatchData) bool {
 		}
 		return false
 
+	case filters.OpVarCalls:
+		e := getMatchExpr(m, f.Str)
+		if call, ok := e.(*ast.CallExpr); ok {
+			e = call.Fun
+		}
+		pkgPath, name, _ := imports.SplitSymbol(f.Value)
+		return ctx.imports.RefersToSymbol(e, pkgPath, name)
+
+	case filters.OpVarRefersToPackage:
+		return ctx.imports.RefersToPackage(getMatchExpr(m, f.Str), f.Value)
+
+	case filters.OpVarStructTagHas:
+		for _, tag := range capturedStructTags(m, f.Str) {
+			if _, ok := structtag.Lookup(tag, f.Value); ok {
+				return true
+			}
+		}
+		return false
+	case filters.OpVarStructTagEq:
+		key, want := decodeStructTagArg(f.Value)
+		for _, tag := range capturedStructTags(m, f.Str) {
+			// A missing key is an empty value, like in reflect.StructTag.Get.
+			if value, _ := structtag.Lookup(tag, key); value == want {
+				return true
+			}
+		}
+		return false
+	case filters.OpVarStructTagHasOption:
+		key, option := decodeStructTagArg(f.Value)
+		for _, tag := range capturedStructTags(m, f.Str) {
+			for _, pair := range structtag.Parse(tag) {
+				if (key == "" || pair.Key == key) && structtag.HasOption(pair.Value, option) {
+					return true
+				}
+			}
+		}
+		return false
+
+	case filters.OpMatchHasComment:
+		return ctx.hasComment(m.Node, ctx.getRegexp(f.Value))
+	case filters.OpMatchFuncHasDoc:
+		fn := ctx.enclosingFunc(m.Node)
+		return fn != nil && fn.Doc != nil
+	case filters.OpMatchIsSuppressed:
+		return ctx.isSuppressed(m.Node, f.Value)
+
+	case filters.OpPkgHasMethod:
+		n, ok := m.CapturedByName(f.Str)
+		return ok && ctx.pkg.hasMethod(n, f.Value)
+	case filters.OpPkgReferences:
+		n, ok := m.CapturedByName(f.Str)
+		return ok && ctx.pkg.references(n)
+
 	default:
 		fmt.Fprintf(os.Stderr, "can't handle %s\n", filters.Sprint(f))
 	}
@@ -165,23 +395,23 @@ func applyFilter(f *filters.Expr, n ast.Node, m gogrep.MatchData) bool {
 	return true
 }
 
-func checkFileDepth(op token.Token, fileDepth, limit int) bool {
+func checkIntCond(op token.Token, x, y int) bool {
 	if op == token.ILLEGAL {
 		return true
 	}
 	switch op {
 	case token.EQL:
-		return fileDepth == limit
+		return x == y
 	case token.NEQ:
-		return fileDepth != limit
+		return x != y
 	case token.LSS:
-		return fileDepth < limit
+		return x < y
 	case token.GTR:
-		return fileDepth > limit
+		return x > y
 	case token.LEQ:
-		return fileDepth <= limit
+		return x <= y
 	case token.GEQ:
-		return fileDepth >= limit
+		return x >= y
 
 	default:
 		return true
@@ -198,44 +428,257 @@ func canSkipFile(cond filters.Bool3, flags, mask int) bool {
 	return false
 }
 
+// canSkipFileByName checks the file module, package path or name against the conds.
+// An empty name means that it's unknown, so it never matches.
+func canSkipFileByName(conds []filters.NamedCond, name string, match func(pattern, s string) bool) bool {
+	for _, c := range conds {
+		matched := name != "" && match(c.Name, name)
+		if c.Cond.IsTrue() != matched {
+			return true
+		}
+	}
+	return false
+}
+
+func matchExact(pattern, s string) bool { return pattern == s }
+
+// canSkipFileByDirectives checks the file directives against the conds.
+// The directives object maps a directive name to the number of its occurrences.
+func canSkipFileByDirectives(conds []filters.NamedCond, directives js.Value) bool {
+	for _, c := range conds {
+		has := directives.Get(c.Name).Truthy()
+		if c.Cond.IsTrue() != has {
+			return true
+		}
+	}
+	return false
+}
+
+// canSkipFileByRequires checks the repository module requirements against the conds.
+// A cond is satisfied if any of the required module paths matches its pattern.
+func canSkipFileByRequires(conds []filters.NamedCond, requires []string) bool {
+	for _, c := range conds {
+		matched := false
+		for _, path := range requires {
+			if imports.MatchPattern(c.Name, path) {
+				matched = true
+				break
+			}
+		}
+		if c.Cond.IsTrue() != matched {
+			return true
+		}
+	}
+	return false
+}
+
+func canSkipFileByBuildTags(info *filters.Info, expr string, filenameTags []string) bool {
+	if !info.ConstrainedFileCond.IsUnset() {
+		isConstrained := expr != "" || len(filenameTags) != 0
+		if info.ConstrainedFileCond.IsTrue() != isConstrained {
+			return true
+		}
+	}
+	for _, c := range info.BuildTagConds {
+		if c.Cond.IsTrue() != buildtags.Mentions(expr, filenameTags, c.Name) {
+			return true
+		}
+	}
+	for _, c := range info.BuildsForConds {
+		goos, goarch, _ := strings.Cut(c.Name, "/")
+		target := buildtags.Target{GOOS: goos, GOARCH: goarch}
+		if c.Cond.IsTrue() != buildtags.Builds(target, expr, filenameTags) {
+			return true
+		}
+	}
+	return false
+}
+
+func jsStrings(v js.Value) []string {
+	if v.IsUndefined() || v.IsNull() {
+		return nil
+	}
+	result := make([]string, v.Length())
+	for i := range result {
+		result[i] = v.Index(i).String()
+	}
+	return result
+}
+
+// patternKinds caches the last pattern required node kinds,
+// as the same pattern is used for every file during the search.
+var patternKinds struct {
+	src   string
+	kinds nodekind.Set
+}
+
+func canSkipFileByNodeKinds(patString, fileNodeKinds string) bool {
+	if fileNodeKinds == "" {
+		return false // Old corpus metadata format
+	}
+	kinds, err := nodekind.Decode(fileNodeKinds)
+	if err != nil {
+		return false
+	}
+	if patternKinds.src != patString {
+		patternKinds.src = patString
+		patternKinds.kinds = pattern.RequiredNodeKinds(patString)
+	}
+	return !kinds.Contains(patternKinds.kinds)
+}
+
 var skipFileResult = map[string]interface{}{
 	"matches": []interface{}{},
 	"skipped": true,
 }
 
-func jsGogrep(this js.Value, args []js.Value) interface{} {
-	argsObject := args[0]
-	patString := argsObject.Get("pattern").String()
-	filterString := argsObject.Get("filter").String()
-	fileFlags := argsObject.Get("fileFlags").Int()
-	fileMaxDepth := argsObject.Get("fileMaxDepth").Int()
-	targetName := argsObject.Get("targetName").String()
-	targetSrc := argsObject.Get("targetSrc").String()
+// noMatchesResult is used when we know that there will be no matches
+// without parsing the file; unlike skipFileResult, the file is still
+// counted as scanned in the search stats.
+var noMatchesResult = map[string]interface{}{
+	"matches": []interface{}{},
+	"skipped": false,
+}
 
-	filterExpr, filterInfo, err := filters.CompileExpr(filterString)
-	if err != nil {
-		return map[string]interface{}{"err": "filter: " + err.Error()}
+// jsPatternIdents returns the identifiers that are required by the pattern.
+// They're used to select the candidate files via the identifiers index.
+func jsPatternIdents(this js.Value, args []js.Value) interface{} {
+	idents := pattern.RequiredIdents(args[0].String())
+	result := make([]interface{}, len(idents))
+	for i, ident := range idents {
+		result[i] = ident
+	}
+	return result
+}
+
+// fileArgs are the per-file gogrep arguments.
+// See gogrepArgs in app.ts.
+type fileArgs struct {
+	flags           int
+	maxDepth        int
+	goVersion       string
+	module          string
+	pkgPath         string
+	pkgName         string
+	nodeKinds       string
+	canMatch        bool
+	directives      js.Value
+	buildConstraint string
+	filenameTags    []string
+	name            string
+	src             string
+
+	// repoRequires are the module paths required by the file repository.
+	// It's only converted to a Go slice if some filter needs it.
+	repoRequires js.Value
+}
+
+func readFileArgs(o js.Value) *fileArgs {
+	return &fileArgs{
+		flags:           o.Get("fileFlags").Int(),
+		maxDepth:        o.Get("fileMaxDepth").Int(),
+		goVersion:       o.Get("fileGoVersion").String(),
+		module:          o.Get("fileModule").String(),
+		pkgPath:         o.Get("filePkgPath").String(),
+		pkgName:         o.Get("filePkgName").String(),
+		nodeKinds:       o.Get("fileNodeKinds").String(),
+		canMatch:        o.Get("fileCanMatch").Bool(),
+		directives:      o.Get("fileDirectives"),
+		buildConstraint: o.Get("fileBuildConstraint").String(),
+		filenameTags:    jsStrings(o.Get("fileFilenameTags")),
+		name:            o.Get("targetName").String(),
+		src:             o.Get("targetSrc").String(),
+		repoRequires:    o.Get("repoRequires"),
+	}
+}
+
+// checkSkipFile checks whether we can skip this file without parsing it.
+// It returns a gogrep result for the skipped file or nil.
+func checkSkipFile(patString string, filterInfo *filters.Info, file *fileArgs) map[string]interface{} {
+	if result := checkFileFilters(filterInfo, file); result != nil {
+		return result
 	}
+	if !file.canMatch || canSkipFileByNodeKinds(patString, file.nodeKinds) {
+		return noMatchesResult
+	}
+	return nil
+}
 
-	// Check whether we can skip this file without parsing it.
-	if !checkFileDepth(filterInfo.FileMaxDepthOp, fileMaxDepth, filterInfo.FileMaxDepth) {
+// checkFileFilters checks the file.* and repo.* filters against the file metadata.
+// It returns skipFileResult if the file should be excluded or nil.
+func checkFileFilters(filterInfo *filters.Info, file *fileArgs) map[string]interface{} {
+	if !checkIntCond(filterInfo.FileMaxDepthOp, file.maxDepth, filterInfo.FileMaxDepth) {
+		return skipFileResult
+	}
+	// Versions are compared by checking the Compare result sign.
+	if !checkIntCond(filterInfo.FileGoVersionOp, goversion.Compare(file.goVersion, filterInfo.FileGoVersion), 0) {
+		return skipFileResult
+	}
+	if canSkipFileByName(filterInfo.FileModuleConds, file.module, imports.MatchPattern) {
+		return skipFileResult
+	}
+	if canSkipFileByName(filterInfo.FilePkgPathConds, file.pkgPath, imports.MatchPattern) {
+		return skipFileResult
+	}
+	if canSkipFileByName(filterInfo.FilePkgNameConds, file.pkgName, matchExact) {
 		return skipFileResult
 	}
-	if canSkipFile(filterInfo.TestFileCond, fileFlags, filebits.IsTest) {
+	if canSkipFile(filterInfo.TestFileCond, file.flags, filebits.IsTest) {
 		return skipFileResult
 	}
-	if canSkipFile(filterInfo.MainFileCond, fileFlags, filebits.IsMain) {
+	if canSkipFile(filterInfo.MainFileCond, file.flags, filebits.IsMain) {
 		return skipFileResult
 	}
-	if canSkipFile(filterInfo.AutogenFileCond, fileFlags, filebits.IsAutogen) {
+	if canSkipFile(filterInfo.AutogenFileCond, file.flags, filebits.IsAutogen) {
 		return skipFileResult
 	}
+	// Duplicates would inflate the frequency stats, so they're
+	// excluded unless the filter asks for them explicitly.
+	duplicateCond := filterInfo.DuplicateFileCond
+	if duplicateCond.IsUnset() {
+		duplicateCond.SetValue(false)
+	}
+	if canSkipFile(duplicateCond, file.flags, filebits.IsDuplicate) {
+		return skipFileResult
+	}
+	// For the heuristic features, FileFlagsSet holds the MayUse* bits
+	// and FileFlagsUnset holds the Uses* bits (see filebits.MayUseGenericInst),
+	// so a file is only skipped if the bits prove that it doesn't match.
+	if file.flags&filterInfo.FileFlagsSet != filterInfo.FileFlagsSet {
+		return skipFileResult
+	}
+	if file.flags&filterInfo.FileFlagsUnset != 0 {
+		return skipFileResult
+	}
+	if canSkipFileByDirectives(filterInfo.DirectiveConds, file.directives) {
+		return skipFileResult
+	}
+	if canSkipFileByBuildTags(filterInfo, file.buildConstraint, file.filenameTags) {
+		return skipFileResult
+	}
+	if len(filterInfo.RepoDependsOnConds) != 0 && canSkipFileByRequires(filterInfo.RepoDependsOnConds, jsStrings(file.repoRequires)) {
+		return skipFileResult
+	}
+	return nil
+}
 
-	fset := token.NewFileSet()
-	f, err := parser.ParseFile(fset, targetName, targetSrc, 0)
-	if err != nil {
-		return map[string]interface{}{"err": "parse Go: " + err.Error()}
+// parseFile parses the target file source.
+//
+// Note that the comments are only available
+// in the unminified (comments-preserving) corpus archives.
+//
+// go/parser doesn't have a language version knob: it always accepts
+// the newest syntax it knows about, so the fileGoVersion can only
+// be used for the filtering, not to select the parsing rules.
+func parseFile(fset *token.FileSet, filterInfo *filters.Info, file *fileArgs) (*ast.File, error) {
+	var parserMode parser.Mode
+	if filterInfo.NeedComments {
+		parserMode |= parser.ParseComments
 	}
+	return parser.ParseFile(fset, file.name, file.src, parserMode)
+}
+
+func compilePattern(fset *token.FileSet, patString string) (*gogrep.Pattern, error) {
 	config := gogrep.CompileConfig{
 		Fset:      fset,
 		Src:       patString,
@@ -243,20 +686,35 @@ func jsGogrep(this js.Value, args []js.Value) interface{} {
 		WithTypes: false,
 	}
 	pat, _, err := gogrep.Compile(config)
-	if err != nil {
-		return map[string]interface{}{"err": "parse pattern: " + err.Error()}
+	return pat, err
+}
+
+// grepFile collects all pattern matches inside f that satisfy the filter.
+// pkg is only set in the package mode.
+func grepFile(fset *token.FileSet, f *ast.File, src string, pat *gogrep.Pattern, filterExpr *filters.Expr, pkg *packageContext) map[string]interface{} {
+	matchCtx := &matchContext{
+		fset:    fset,
+		file:    f,
+		imports: imports.NewTable(f),
+		pkg:     pkg,
+		regexps: make(map[string]*regexp.Regexp),
 	}
 
 	var matches []interface{}
 	state := gogrep.NewMatcherState()
 	ast.Inspect(f, func(n ast.Node) bool {
+		if n == nil {
+			matchCtx.stack = matchCtx.stack[:len(matchCtx.stack)-1]
+			return true
+		}
 		pat.MatchNode(&state, n, func(m gogrep.MatchData) {
-			if filterExpr.Op == filters.OpNop || applyFilter(filterExpr, m.Node, m) {
+			if filterExpr.Op == filters.OpNop || applyFilter(matchCtx, filterExpr, m.Node, m) {
 				begin := fset.Position(m.Node.Pos()).Offset
 				end := fset.Position(m.Node.End()).Offset
-				matches = append(matches, targetSrc[begin:end])
+				matches = append(matches, src[begin:end])
 			}
 		})
+		matchCtx.stack = append(matchCtx.stack, n)
 		return true
 	})
 	return map[string]interface{}{
@@ -264,3 +722,93 @@ func jsGogrep(this js.Value, args []js.Value) interface{} {
 		"skipped": false,
 	}
 }
+
+func jsGogrep(this js.Value, args []js.Value) interface{} {
+	argsObject := args[0]
+	patString := argsObject.Get("pattern").String()
+	filterString := argsObject.Get("filter").String()
+	file := readFileArgs(argsObject)
+
+	filterExpr, filterInfo, err := filters.CompileExpr(filterString)
+	if err != nil {
+		return map[string]interface{}{"err": "filter: " + err.Error()}
+	}
+	if filterInfo.NeedPackage {
+		return map[string]interface{}{"err": "filter: pkg filters are only available in the package mode"}
+	}
+	if filterInfo.NeedDecl {
+		return map[string]interface{}{"err": "filter: decl filters are only available in the decl search mode"}
+	}
+
+	if result := checkSkipFile(patString, &filterInfo, file); result != nil {
+		return result
+	}
+
+	fset := token.NewFileSet()
+	f, err := parseFile(fset, &filterInfo, file)
+	if err != nil {
+		return map[string]interface{}{"err": "parse Go: " + err.Error()}
+	}
+	pat, err := compilePattern(fset, patString)
+	if err != nil {
+		return map[string]interface{}{"err": "parse pattern: " + err.Error()}
+	}
+	return grepFile(fset, f, file.src, pat, filterExpr, nil)
+}
+
+// jsGogrepPackage runs the query over all files of one package.
+// The matching is still done per file, but the filters
+// can use the package-wide information via the pkg object.
+//
+// The args object contains the pattern, filter and
+// a files array of the per-file gogrep arguments.
+// The result contains a gogrep result for every file.
+func jsGogrepPackage(this js.Value, args []js.Value) interface{} {
+	argsObject := args[0]
+	patString := argsObject.Get("pattern").String()
+	filterString := argsObject.Get("filter").String()
+	filesArray := argsObject.Get("files")
+
+	filterExpr, filterInfo, err := filters.CompileExpr(filterString)
+	if err != nil {
+		return map[string]interface{}{"err": "filter: " + err.Error()}
+	}
+	if filterInfo.NeedDecl {
+		return map[string]interface{}{"err": "filter: decl filters are only available in the decl search mode"}
+	}
+
+	// All package files are parsed, even the ones that are skipped
+	// by the filters: the package context should see the whole package.
+	fset := token.NewFileSet()
+	files := make([]*fileArgs, filesArray.Length())
+	parsed := make([]*ast.File, len(files))
+	parseErrors := make([]error, len(files))
+	var pkgFiles []*ast.File
+	for i := range files {
+		files[i] = readFileArgs(filesArray.Index(i))
+		parsed[i], parseErrors[i] = parseFile(fset, &filterInfo, files[i])
+		if parseErrors[i] == nil {
+			pkgFiles = append(pkgFiles, parsed[i])
+		}
+	}
+	pkg := newPackageContext(pkgFiles)
+
+	pat, err := compilePattern(fset, patString)
+	if err != nil {
+		return map[string]interface{}{"err": "parse pattern: " + err.Error()}
+	}
+
+	results := make([]interface{}, len(files))
+	for i, file := range files {
+		if result := checkSkipFile(patString, &filterInfo, file); result != nil {
+			results[i] = result
+			continue
+		}
+		if parseErrors[i] != nil {
+			results[i] = map[string]interface{}{"err": "parse Go: " + parseErrors[i].Error()}
+			continue
+		}
+		results[i] = grepFile(fset, parsed[i], file.src, pat, filterExpr, pkg)
+	}
+	return map[string]interface{}{"results": results}
+}